@@ -95,8 +95,18 @@ func (a *BaseAction) PrepareRequest(req IBaseRequest, ctxFunc BaseCtxFunc, bindF
 		}
 	}
 
-	// 设置上下文
-	ctx := ctxFunc(context.Background(), a.Context)
+	// 设置上下文 基于请求的上下文 客户端断开或超时后会被取消
+	ctx := ctxFunc(a.Context.Request.Context(), a.Context)
+
+	// 保存请求的语言 服务中可通过 LocaleFromContext 获取
+	if _, ok := LocaleFromContext(ctx); !ok {
+		ctx = WithLocale(ctx, RequestLocale(a.Context))
+	}
+
+	// 客户端通过 If-Match 传入版本号时保存到上下文 服务中通过 IfMatchFromContext 获取后传给 UpdateOneIfMatch
+	if version, ok := ParseETag(a.Context.GetHeader("If-Match")); ok {
+		ctx = WithIfMatch(ctx, version)
+	}
 
 	// ⚠️ 检查：ctxFunc 中可能已经返回了响应（如权限检查失败）
 	if a.Context.Writer.Written() {
@@ -0,0 +1,275 @@
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AggFunc 聚合函数
+type AggFunc string
+
+const (
+	AggCount AggFunc = "count" // 计数 字段为空时为 COUNT(*)
+	AggSum   AggFunc = "sum"   // 求和
+	AggAvg   AggFunc = "avg"   // 平均值
+	AggMin   AggFunc = "min"   // 最小值
+	AggMax   AggFunc = "max"   // 最大值
+)
+
+// Metric 客户端传入的聚合指标
+type Metric struct {
+	Field string  `json:"field" form:"field"`
+	Func  AggFunc `json:"func" form:"func"`
+}
+
+// Name 指标在结果中的名称 例如 sum_amount 字段为空的计数为 count
+func (m Metric) Name() string {
+	if m.Field == "" {
+		return string(m.Func)
+	}
+	return string(m.Func) + "_" + m.Field
+}
+
+// AggregateRequest 客户端传入的聚合请求 未传指标时统计条数
+type AggregateRequest struct {
+	GroupBy []string `json:"group_by" form:"group_by"`
+	Metrics []Metric `json:"metrics" form:"metrics"`
+}
+
+// MetricRule 字段的聚合规则
+type MetricRule struct {
+	Column string    // 数据库列名
+	Funcs  []AggFunc // 允许的聚合函数 为空时只允许count
+}
+
+// AggregateWhitelist 允许分组和聚合的字段白名单 key为对外暴露的字段名
+//
+//	whitelist := helper.AggregateWhitelist{
+//		GroupBy: map[string]string{"status": "status"},
+//		Metrics: map[string]helper.MetricRule{
+//			"amount": {Column: "amount", Funcs: []helper.AggFunc{helper.AggSum, helper.AggAvg}},
+//		},
+//	}
+type AggregateWhitelist struct {
+	GroupBy map[string]string
+	Metrics map[string]MetricRule
+}
+
+// AggregateRow 一组聚合结果
+type AggregateRow struct {
+	Group  map[string]interface{} `json:"group"`  // 分组字段的值 key为字段名
+	Values map[string]float64     `json:"values"` // 指标的值 key为 Metric.Name
+}
+
+// AggregateResult 聚合结果
+type AggregateResult struct {
+	GroupBy []string       `json:"group_by"`
+	Metrics []string       `json:"metrics"`
+	Rows    []AggregateRow `json:"rows"`
+}
+
+// ChartSeries 图表的一个数据系列
+type ChartSeries struct {
+	Name string    `json:"name"`
+	Data []float64 `json:"data"`
+}
+
+// ChartData 图表数据 Labels 为横轴 每个指标为一个系列
+type ChartData struct {
+	Labels []string      `json:"labels"`
+	Series []ChartSeries `json:"series"`
+}
+
+// Chart 转换为图表数据 多个分组字段的值使用"/"拼接为横轴标签
+func (r *AggregateResult) Chart() *ChartData {
+	chart := &ChartData{
+		Labels: make([]string, 0, len(r.Rows)),
+		Series: make([]ChartSeries, 0, len(r.Metrics)),
+	}
+	for _, name := range r.Metrics {
+		chart.Series = append(chart.Series, ChartSeries{Name: name, Data: make([]float64, 0, len(r.Rows))})
+	}
+	for _, row := range r.Rows {
+		labels := make([]string, 0, len(r.GroupBy))
+		for _, field := range r.GroupBy {
+			labels = append(labels, fmt.Sprint(row.Group[field]))
+		}
+		chart.Labels = append(chart.Labels, strings.Join(labels, "/"))
+		for i, name := range r.Metrics {
+			chart.Series[i].Data = append(chart.Series[i].Data, row.Values[name])
+		}
+	}
+	return chart
+}
+
+// Aggregate 按分页请求的筛选条件分组统计 分组字段和指标需要在白名单内 结果按分组字段正序
+// 分页和排序参数会被忽略
+//
+//	result, err := dao.Aggregate(page, helper.AggregateRequest{
+//		GroupBy: []string{"status"},
+//		Metrics: []helper.Metric{{Func: helper.AggCount}, {Field: "amount", Func: helper.AggSum}},
+//	}, whitelist)
+func (u *Util[T]) Aggregate(request *PageRequest, aggregate AggregateRequest, whitelist AggregateWhitelist) (*AggregateResult, error) {
+	db, metrics, err := u.aggregateDB(request, aggregate, whitelist)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]interface{}, 0)
+	if err = db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := &AggregateResult{
+		GroupBy: append([]string{}, aggregate.GroupBy...),
+		Metrics: make([]string, 0, len(metrics)),
+		Rows:    make([]AggregateRow, 0, len(rows)),
+	}
+	for _, metric := range metrics {
+		result.Metrics = append(result.Metrics, metric.Name())
+	}
+	for _, row := range rows {
+		item := AggregateRow{
+			Group:  make(map[string]interface{}, len(aggregate.GroupBy)),
+			Values: make(map[string]float64, len(metrics)),
+		}
+		for _, field := range aggregate.GroupBy {
+			item.Group[field] = aggregateValue(row[field])
+		}
+		for _, name := range result.Metrics {
+			item.Values[name] = aggregateFloat(row[name])
+		}
+		result.Rows = append(result.Rows, item)
+	}
+	return result, nil
+}
+
+// AggregateAs 分组统计并将结果扫描到R 分组字段的列名为字段名 指标的列名为 Metric.Name
+//
+//	type StatusStat struct {
+//		Status    int
+//		Count     int64
+//		SumAmount float64
+//	}
+//	stats, err := helper.AggregateAs[models.Order, StatusStat](dao, page, aggregate, whitelist)
+func AggregateAs[T any, R any](u *Util[T], request *PageRequest, aggregate AggregateRequest, whitelist AggregateWhitelist) ([]R, error) {
+	db, _, err := u.aggregateDB(request, aggregate, whitelist)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]R, 0)
+	// Scan 需要非nil的模型实例
+	if err = db.Model(new(T)).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// aggregateDB 校验聚合请求并生成查询
+func (u *Util[T]) aggregateDB(request *PageRequest, aggregate AggregateRequest, whitelist AggregateWhitelist) (*gorm.DB, []Metric, error) {
+	metrics := aggregate.Metrics
+	if len(metrics) == 0 {
+		metrics = []Metric{{Func: AggCount}}
+	}
+	db, err := u.listDB(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request != nil {
+		db = request.buildWhere(db)
+	}
+	quote := db.Statement.Quote
+	selects := make([]string, 0, len(aggregate.GroupBy)+len(metrics))
+	groups := make([]string, 0, len(aggregate.GroupBy))
+	for _, field := range aggregate.GroupBy {
+		column, ok := whitelist.GroupBy[field]
+		if !ok {
+			return nil, nil, NewErrorModel(ERROR, "不支持的分组字段: "+field, nil, http.StatusBadRequest)
+		}
+		groups = append(groups, quote(column))
+		selects = append(selects, quote(column)+" AS "+quote(field))
+	}
+	for _, metric := range metrics {
+		expr, err := metric.expr(whitelist, quote)
+		if err != nil {
+			return nil, nil, err
+		}
+		selects = append(selects, expr+" AS "+quote(metric.Name()))
+	}
+	db = db.Select(strings.Join(selects, ", "))
+	for _, group := range groups {
+		db = db.Group(group).Order(group)
+	}
+	return db, metrics, nil
+}
+
+// expr 校验指标并生成聚合表达式
+func (m Metric) expr(whitelist AggregateWhitelist, quote func(field interface{}) string) (string, error) {
+	switch m.Func {
+	case AggCount, AggSum, AggAvg, AggMin, AggMax:
+	default:
+		return "", NewErrorModel(ERROR, fmt.Sprintf("不支持的聚合函数: %s", m.Func), nil, http.StatusBadRequest)
+	}
+	if m.Field == "" {
+		if m.Func != AggCount {
+			return "", NewErrorModel(ERROR, fmt.Sprintf("聚合函数%s需要指定字段", m.Func), nil, http.StatusBadRequest)
+		}
+		return "COUNT(*)", nil
+	}
+	rule, ok := whitelist.Metrics[m.Field]
+	if !ok {
+		return "", NewErrorModel(ERROR, "不支持的聚合字段: "+m.Field, nil, http.StatusBadRequest)
+	}
+	if !rule.allow(m.Func) {
+		return "", NewErrorModel(ERROR, fmt.Sprintf("字段%s不支持聚合函数: %s", m.Field, m.Func), nil, http.StatusBadRequest)
+	}
+	return strings.ToUpper(string(m.Func)) + "(" + quote(rule.Column) + ")", nil
+}
+
+// allow 判断聚合函数是否允许
+func (r MetricRule) allow(fn AggFunc) bool {
+	if len(r.Funcs) == 0 {
+		return fn == AggCount
+	}
+	for _, f := range r.Funcs {
+		if f == fn {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregateValue 分组字段的值 部分驱动返回[]byte 转为字符串
+func aggregateValue(value interface{}) interface{} {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return value
+}
+
+// aggregateFloat 将聚合结果转为float64 空值为0
+func aggregateFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case []byte:
+		f, _ := strconv.ParseFloat(string(v), 64)
+		return f
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
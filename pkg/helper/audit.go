@@ -0,0 +1,338 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 审计字段的列名 模型包含这些列且上下文中有操作人时自动写入
+var (
+	AuditCreatedByColumn = "created_by"
+	AuditUpdatedByColumn = "updated_by"
+)
+
+// AuditAction 审计的操作类型
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditLog 变更记录
+type AuditLog struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	RecordTable string      `gorm:"size:64;index:idx_audit_record" json:"record_table"`
+	RecordID    string      `gorm:"size:64;index:idx_audit_record" json:"record_id"`
+	Action      AuditAction `gorm:"size:16" json:"action"`
+	Actor       string      `gorm:"size:64" json:"actor"`
+	Before      string      `gorm:"type:text" json:"before"`  // 变更前的记录 JSON
+	After       string      `gorm:"type:text" json:"after"`   // 变更后的记录 JSON
+	Changes     string      `gorm:"type:text" json:"changes"` // 变更的字段 JSON {"列名": {"old": 旧值, "new": 新值}}
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// AuditSink 变更记录的存储
+type AuditSink interface {
+	Record(ctx context.Context, log *AuditLog) error
+}
+
+// AuditHistory 支持查询变更历史的存储
+type AuditHistory interface {
+	History(ctx context.Context, table string, recordID string, request *PageRequest) (*PageList[AuditLog], error)
+}
+
+// GormAuditSink 将变更记录写入数据库表 上下文中有同一数据库的事务时在事务中写入
+type GormAuditSink struct {
+	DB    *gorm.DB
+	Table string
+}
+
+// NewGormAuditSink 创建数据库存储 table 为空时使用 audit_logs
+func NewGormAuditSink(db *gorm.DB, table string) *GormAuditSink {
+	if table == "" {
+		table = "audit_logs"
+	}
+	return &GormAuditSink{DB: db, Table: table}
+}
+
+// AutoMigrate 创建变更记录表
+func (s *GormAuditSink) AutoMigrate() error {
+	return s.DB.Table(s.Table).AutoMigrate(&AuditLog{})
+}
+
+// conn 获取连接 优先使用上下文中同一数据库的事务
+func (s *GormAuditSink) conn(ctx context.Context) *gorm.DB {
+	db := s.DB
+	if tx, ok := TxFromContext(ctx, s.DB); ok {
+		db = tx
+	}
+	if ctx != nil {
+		db = db.WithContext(ctx)
+	}
+	return db
+}
+
+// Record 写入变更记录
+func (s *GormAuditSink) Record(ctx context.Context, log *AuditLog) error {
+	return s.conn(ctx).Table(s.Table).Create(log).Error
+}
+
+// History 分页查询记录的变更历史 按时间倒序
+func (s *GormAuditSink) History(ctx context.Context, table string, recordID string, request *PageRequest) (*PageList[AuditLog], error) {
+	if request == nil {
+		request = NewPageReq()
+	}
+	db := s.conn(ctx).Model(&AuditLog{}).Table(s.Table).
+		Where(clause.Eq{Column: clause.Column{Name: "record_table"}, Value: table}).
+		Where(clause.Eq{Column: clause.Column{Name: "record_id"}, Value: recordID}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: true})
+	list := NewPageList[AuditLog]()
+	list.Data = make([]AuditLog, 0)
+	if err := findPage(db, request, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// actorContextKey 操作人在上下文中的key
+type actorContextKey struct{}
+
+// WithActor 将操作人保存到上下文 一般在 BaseCtxFunc 中根据登录信息设置
+// 操作人的类型需要与 created_by/updated_by 列的类型一致
+func WithActor(ctx context.Context, actor interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext 获取上下文中的操作人
+func ActorFromContext(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	actor := ctx.Value(actorContextKey{})
+	return actor, actor != nil
+}
+
+// SetAudit 设置变更记录的存储 设置后 CreateOne UpdateOne UpdateOneColumn DeleteOne 会记录变更前后的数据
+func (u *Util[T]) SetAudit(sink AuditSink) {
+	u.audit = sink
+}
+
+// History 查询记录的变更历史 存储需要实现 AuditHistory
+// 开启租户隔离时记录需要属于上下文中的租户 否则返回 gorm.ErrRecordNotFound
+func (u *Util[T]) History(model *T, request *PageRequest) (*PageList[AuditLog], error) {
+	history, ok := u.audit.(AuditHistory)
+	if !ok {
+		return nil, errors.New("变更记录的存储不支持查询历史")
+	}
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	recordID, err := u.primaryValue(model)
+	if err != nil {
+		return nil, err
+	}
+	// 变更记录不区分租户 先确认记录在当前租户下可见 防止读取其他租户的数据
+	if u.tenantField() != nil && !isSkipTenant(u.ctx) {
+		current, err := u.findByPrimaryKey(model)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+	}
+	return history.History(u.ctx, sch.Table, fmt.Sprint(recordID), request)
+}
+
+// stampActor 将上下文中的操作人写入审计字段 返回实际写入的列名
+func (u *Util[T]) stampActor(model *T, action AuditAction) ([]string, error) {
+	actor, ok := ActorFromContext(u.ctx)
+	if !ok {
+		return nil, nil
+	}
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	columns := []string{AuditUpdatedByColumn}
+	if action == AuditCreate {
+		columns = append(columns, AuditCreatedByColumn)
+	}
+	stamped := make([]string, 0, len(columns))
+	rv := reflect.ValueOf(model).Elem()
+	for _, column := range columns {
+		field := sch.FieldsByDBName[column]
+		if field == nil {
+			continue
+		}
+		if err = field.Set(context.Background(), rv, actor); err != nil {
+			return nil, err
+		}
+		stamped = append(stamped, column)
+	}
+	return stamped, nil
+}
+
+// primaryValue 获取记录的主键值
+func (u *Util[T]) primaryValue(model *T) (interface{}, error) {
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("模型%s没有主键", sch.Name)
+	}
+	value, _ := sch.PrioritizedPrimaryField.ValueOf(context.Background(), reflect.ValueOf(model).Elem())
+	return value, nil
+}
+
+// findByPrimaryKey 根据model的主键查询当前记录 包括已软删除的记录 不存在时返回nil
+func (u *Util[T]) findByPrimaryKey(model *T) (*T, error) {
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	value, err := u.primaryValue(model)
+	if err != nil {
+		return nil, err
+	}
+	current := new(T)
+	err = u.conn().Unscoped().Model(u.Model).Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: sch.PrioritizedPrimaryField.DBName},
+		Value:  value,
+	}).First(current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return current, err
+}
+
+// errNoRowsAffected 写操作没有影响任何记录 audited 不记录变更
+var errNoRowsAffected = errors.New("没有影响任何记录")
+
+// affected 获取写操作的错误 没有影响任何记录时返回 errNoRowsAffected
+func affected(db *gorm.DB) error {
+	if db.Error == nil && db.RowsAffected == 0 {
+		return errNoRowsAffected
+	}
+	return db.Error
+}
+
+// audited 执行写操作并记录变更 未设置存储时直接执行
+// 查询变更前的数据 写操作 查询变更后的数据 写入记录 在同一个事务中完成
+// write 返回 errNoRowsAffected 时不记录变更 不作为错误返回
+func (u *Util[T]) audited(action AuditAction, model *T, write func(u *Util[T]) error) error {
+	if u.audit == nil {
+		if err := write(u); !errors.Is(err, errNoRowsAffected) {
+			return err
+		}
+		return nil
+	}
+	return WithTx(u.ctx, u.DB, func(ctx context.Context) error {
+		tu := u.WithContext(ctx)
+		var before, after *T
+		var err error
+		if action != AuditCreate {
+			if before, err = tu.findByPrimaryKey(model); err != nil {
+				return err
+			}
+		}
+		if err = write(tu); err != nil {
+			if errors.Is(err, errNoRowsAffected) {
+				return nil
+			}
+			return err
+		}
+		switch action {
+		case AuditCreate:
+			after = model
+		case AuditUpdate:
+			if after, err = tu.findByPrimaryKey(model); err != nil {
+				return err
+			}
+		}
+		if before == nil && after == nil {
+			return nil
+		}
+		return tu.recordAudit(ctx, action, model, before, after)
+	})
+}
+
+// recordAudit 生成并写入变更记录
+func (u *Util[T]) recordAudit(ctx context.Context, action AuditAction, model, before, after *T) error {
+	sch, err := u.schema()
+	if err != nil {
+		return err
+	}
+	recordID, err := u.primaryValue(model)
+	if err != nil {
+		return err
+	}
+	log := &AuditLog{
+		RecordTable: sch.Table,
+		RecordID:    fmt.Sprint(recordID),
+		Action:      action,
+		CreatedAt:   time.Now(),
+	}
+	if actor, ok := ActorFromContext(ctx); ok {
+		log.Actor = fmt.Sprint(actor)
+	}
+	beforeValues := u.columnValues(before)
+	afterValues := u.columnValues(after)
+	changes := make(map[string]map[string]interface{})
+	for _, column := range sch.DBNames {
+		oldValue, newValue := beforeValues[column], afterValues[column]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[column] = map[string]interface{}{"old": oldValue, "new": newValue}
+		}
+	}
+	if log.Before, err = marshalAudit(beforeValues); err != nil {
+		return err
+	}
+	if log.After, err = marshalAudit(afterValues); err != nil {
+		return err
+	}
+	if log.Changes, err = marshalAudit(changes); err != nil {
+		return err
+	}
+	return u.audit.Record(ctx, log)
+}
+
+// columnValues 获取记录各列的值 model为nil时返回nil
+func (u *Util[T]) columnValues(model *T) map[string]interface{} {
+	if model == nil {
+		return nil
+	}
+	sch, err := u.schema()
+	if err != nil {
+		return nil
+	}
+	rv := reflect.ValueOf(model).Elem()
+	values := make(map[string]interface{}, len(sch.DBNames))
+	for _, column := range sch.DBNames {
+		values[column], _ = sch.FieldsByDBName[column].ValueOf(context.Background(), rv)
+	}
+	return values
+}
+
+// marshalAudit 序列化为JSON 空值返回空字符串
+func marshalAudit(value interface{}) (string, error) {
+	if reflect.ValueOf(value).Len() == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
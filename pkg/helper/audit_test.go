@@ -0,0 +1,164 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type auditItem struct {
+	ID        uint
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+// memoryAuditSink 记录到内存的审计存储
+type memoryAuditSink struct {
+	lock sync.Mutex
+	logs []AuditLog
+}
+
+func (s *memoryAuditSink) Record(_ context.Context, log *AuditLog) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.logs = append(s.logs, *log)
+	return nil
+}
+
+// actions 已记录的操作类型
+func (s *memoryAuditSink) actions() []AuditAction {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	actions := make([]AuditAction, 0, len(s.logs))
+	for _, log := range s.logs {
+		actions = append(actions, log.Action)
+	}
+	return actions
+}
+
+// setupAudit 写入一条记录并开启审计
+func setupAudit(t *testing.T) (*gorm.DB, *GormRepository[auditItem], *memoryAuditSink) {
+	t.Helper()
+	db := openTestDB(t, &auditItem{})
+	if err := db.Create(&auditItem{ID: 1, Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	sink := &memoryAuditSink{}
+	repo := NewGormRepository[auditItem](db)
+	repo.SetAudit(sink)
+	return db, repo, sink
+}
+
+func assertActions(t *testing.T, sink *memoryAuditSink, want ...AuditAction) {
+	t.Helper()
+	got := sink.actions()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestAuditDeleteById(t *testing.T) {
+	_, repo, sink := setupAudit(t)
+	if err := repo.DeleteById(1); err != nil {
+		t.Fatal(err)
+	}
+	assertActions(t, sink, AuditDelete)
+	if sink.logs[0].RecordID != "1" {
+		t.Fatalf("got record id %q, want 1", sink.logs[0].RecordID)
+	}
+}
+
+func TestAuditSoftDeleteRestore(t *testing.T) {
+	db, repo, sink := setupAudit(t)
+	if err := repo.SoftDelete(&auditItem{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Restore(&auditItem{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ForceDelete(&auditItem{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	assertActions(t, sink, AuditDelete, AuditUpdate, AuditDelete)
+	if sink.logs[1].Changes == "{}" {
+		t.Fatal("恢复记录应记录 deleted_at 的变更")
+	}
+	var count int64
+	db.Unscoped().Model(&auditItem{}).Count(&count)
+	if count != 0 {
+		t.Fatal("ForceDelete 应物理删除记录")
+	}
+}
+
+func TestAuditHistoryTenant(t *testing.T) {
+	db, u := setupTenant(t)
+	sink := NewGormAuditSink(db, "")
+	if err := sink.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+	u.SetAudit(sink)
+	for _, id := range []string{"1", "3"} {
+		log := &AuditLog{RecordTable: "isolated_items", RecordID: id, Action: AuditUpdate}
+		if err := sink.Record(context.Background(), log); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := u.History(&isolatedItem{ID: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 1 {
+		t.Fatalf("期望1条变更记录 实际%d条", list.Total)
+	}
+	if _, err = u.History(&isolatedItem{ID: 3}, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("其他租户的记录应返回不存在 实际 %v", err)
+	}
+}
+
+func TestAuditSkipNoRowsAffected(t *testing.T) {
+	_, repo, sink := setupAudit(t)
+	for i := 0; i < 2; i++ {
+		if err := repo.SoftDelete(&auditItem{ID: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.DeleteOne(&auditItem{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	assertActions(t, sink, AuditDelete)
+}
+
+type actorItem struct {
+	ID        uint
+	Name      string
+	UpdatedBy uint
+}
+
+func TestUpdateOneColumnKeepsCallerSlice(t *testing.T) {
+	db := openTestDB(t, &actorItem{})
+	if err := db.Create(&actorItem{ID: 1, Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	u := NewUtil[actorItem](db).WithContext(WithActor(context.Background(), uint(7)))
+	columns := make([]string, 1, 4)
+	columns[0] = "name"
+	if err := u.UpdateOneColumn(&actorItem{ID: 1, Name: "b"}, columns...); err != nil {
+		t.Fatal(err)
+	}
+	if spare := columns[:cap(columns)][1]; spare != "" {
+		t.Fatalf("不应写入调用方切片的底层数组: %q", spare)
+	}
+	var item actorItem
+	db.First(&item, 1)
+	if item.Name != "b" || item.UpdatedBy != 7 {
+		t.Fatalf("got %+v", item)
+	}
+}
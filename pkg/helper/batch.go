@@ -0,0 +1,132 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize 批量操作默认每批的条数
+const DefaultBatchSize = 500
+
+// BatchProgress 批量操作的进度
+type BatchProgress struct {
+	Batch         int   // 当前批次 从1开始
+	BatchSize     int   // 当前批次的条数
+	RowsAffected  int64 // 当前批次影响的行数
+	Processed     int   // 累计处理的条数
+	TotalAffected int64 // 累计影响的行数
+	Total         int   // 总条数 流式查询时为0
+}
+
+// BatchProgressFunc 批量操作的进度回调 每批完成后调用
+type BatchProgressFunc func(progress BatchProgress)
+
+// CreateInBatches 分批创建多条记录 所有批次在同一个事务中执行 返回影响的总行数
+func (u *Util[T]) CreateInBatches(models []T, size int, progress ...BatchProgressFunc) (int64, error) {
+	return u.execInBatches(models, size, progress, func(db *gorm.DB, batch []T) *gorm.DB {
+		return db.Model(u.Model).Create(batch)
+	})
+}
+
+// Upsert 分批插入或更新 conflictColumns 为唯一约束的列 updateColumns 为冲突时更新的列 为空时更新所有列
+// 开启租户隔离时 唯一约束需要包含租户列 否则可能更新到其他租户的记录
+// 所有批次在同一个事务中执行 每批条数为 DefaultBatchSize 返回影响的总行数
+func (u *Util[T]) Upsert(models []T, conflictColumns []string, updateColumns []string, progress ...BatchProgressFunc) (int64, error) {
+	sch, err := u.schema()
+	if err != nil {
+		return 0, err
+	}
+	onConflict := clause.OnConflict{}
+	for _, name := range conflictColumns {
+		field := sch.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return 0, NewErrorModel(ERROR, "不支持的冲突字段: "+name, nil, http.StatusBadRequest)
+		}
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: field.DBName})
+	}
+	if len(updateColumns) == 0 {
+		onConflict.UpdateAll = true
+	} else {
+		columns := make([]string, 0, len(updateColumns))
+		for _, name := range updateColumns {
+			field := sch.LookUpField(name)
+			if field == nil || field.DBName == "" {
+				return 0, NewErrorModel(ERROR, "不支持的更新字段: "+name, nil, http.StatusBadRequest)
+			}
+			columns = append(columns, field.DBName)
+		}
+		onConflict.DoUpdates = clause.AssignmentColumns(columns)
+	}
+	affected, err := u.execInBatches(models, DefaultBatchSize, progress, func(db *gorm.DB, batch []T) *gorm.DB {
+		return db.Model(u.Model).Clauses(onConflict).Create(batch)
+	})
+	return affected, u.evictAfter(models, err)
+}
+
+// FindInBatches 按 PageRequest 的条件分批流式查询 按主键顺序每次查询size条 fn返回错误时停止
+// 分页和排序参数会被忽略
+func (u *Util[T]) FindInBatches(request *PageRequest, size int, fn func(batch []T, progress BatchProgress) error) error {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	db, err := u.listDB(nil)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		db = request.buildWhere(db)
+	}
+	batch := make([]T, 0, size)
+	progress := BatchProgress{}
+	return db.FindInBatches(&batch, size, func(tx *gorm.DB, batchNo int) error {
+		progress.Batch = batchNo
+		progress.BatchSize = len(batch)
+		progress.RowsAffected = tx.RowsAffected
+		progress.Processed += len(batch)
+		progress.TotalAffected += tx.RowsAffected
+		return fn(batch, progress)
+	}).Error
+}
+
+// execInBatches 在事务中分批执行写操作
+func (u *Util[T]) execInBatches(models []T, size int, progress []BatchProgressFunc, exec func(db *gorm.DB, batch []T) *gorm.DB) (int64, error) {
+	if len(models) == 0 {
+		return 0, nil
+	}
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if err := u.stampTenant(models); err != nil {
+		return 0, err
+	}
+	state := BatchProgress{Total: len(models)}
+	err := WithTx(u.ctx, u.DB, func(ctx context.Context) error {
+		db := u.WithContext(ctx).conn()
+		for start := 0; start < len(models); start += size {
+			end := start + size
+			if end > len(models) {
+				end = len(models)
+			}
+			result := exec(db, models[start:end])
+			if result.Error != nil {
+				return result.Error
+			}
+			state.Batch++
+			state.BatchSize = end - start
+			state.RowsAffected = result.RowsAffected
+			state.Processed = end
+			state.TotalAffected += result.RowsAffected
+			for _, fn := range progress {
+				fn(state)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return state.TotalAffected, nil
+}
@@ -0,0 +1,325 @@
+package helper
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CacheKeyPrefix 缓存key的前缀 key格式为 前缀+表名:主键
+var CacheKeyPrefix = "gf:"
+
+// Cache 缓存接口 可基于Redis等实现
+type Cache interface {
+	// Get 获取缓存 不存在或已过期时返回false
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set 设置缓存 ttl 小于等于0时不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// LRUCache 内存LRU缓存 超过容量时淘汰最久未使用的数据
+type LRUCache struct {
+	capacity int
+	lock     sync.Mutex
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+// lruEntry LRU缓存的数据
+type lruEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+// NewLRUCache 创建内存LRU缓存 capacity 为最多缓存的条数
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &LRUCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get 获取缓存
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		c.order.Remove(elem)
+		delete(c.items, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set 设置缓存
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry := &lruEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expireAt = time.Now().Add(ttl)
+	}
+	if elem, ok := c.items[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return nil
+	}
+	c.items[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// Delete 删除缓存
+func (c *LRUCache) Delete(_ context.Context, keys ...string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.order.Remove(elem)
+			delete(c.items, key)
+		}
+	}
+	return nil
+}
+
+// flightGroup 合并相同key的并发加载 防止缓存击穿
+type flightGroup struct {
+	lock  sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall 正在进行的加载
+type flightCall struct {
+	wg    sync.WaitGroup
+	value []byte
+	err   error
+}
+
+// Do 执行加载 同一个key同时只执行一次 其他调用等待并共享结果
+func (g *flightGroup) Do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.lock.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.lock.Unlock()
+		call.wg.Wait()
+		return call.value, call.err
+	}
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.lock.Unlock()
+
+	defer func() {
+		call.wg.Done()
+		g.lock.Lock()
+		delete(g.calls, key)
+		g.lock.Unlock()
+	}()
+	call.value, call.err = fn()
+	return call.value, call.err
+}
+
+// cacheConfig Util的缓存配置
+type cacheConfig struct {
+	cache Cache
+	ttl   time.Duration
+	group *flightGroup
+}
+
+// SetCache 设置主键查询的缓存 设置后 GetById 优先读取缓存
+// 更新 删除记录后自动删除对应的缓存 事务中及强制读主库时不使用缓存
+//
+//	dao.SetCache(helper.NewLRUCache(10000), 5*time.Minute)
+func (u *Util[T]) SetCache(cache Cache, ttl time.Duration) {
+	if cache == nil {
+		u.cache = nil
+		return
+	}
+	u.cache = &cacheConfig{cache: cache, ttl: ttl, group: &flightGroup{}}
+}
+
+// GetById 根据主键获取一条记录 设置了缓存时使用缓存 记录不存在时返回 gorm.ErrRecordNotFound
+func (u *Util[T]) GetById(id interface{}) (*T, error) {
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("模型%s没有主键", sch.Name)
+	}
+	load := func(db *gorm.DB) (*T, error) {
+		model := new(T)
+		err := db.Model(u.Model).Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: sch.PrioritizedPrimaryField.DBName},
+			Value:  id,
+		}).First(model).Error
+		return model, err
+	}
+	if !u.cacheable() {
+		return load(u.reader())
+	}
+
+	ctx := u.cacheContext()
+	key := u.cacheKey(id)
+	if data, ok, err := u.cache.cache.Get(ctx, key); err == nil && ok {
+		if model, err := decodeCache[T](data); err == nil && u.sameTenant(model) {
+			return model, nil
+		}
+	}
+	// 不同租户分别加载 避免共享其他租户的查询结果
+	tenantID, _ := TenantFromContext(u.ctx)
+	data, err := u.cache.group.Do(fmt.Sprintf("%s#%v", key, tenantID), func() ([]byte, error) {
+		// 从主库加载 避免缓存从库延迟的旧数据
+		model, err := load(u.conn())
+		if err != nil {
+			return nil, err
+		}
+		data, err := encodeCache(model)
+		if err != nil {
+			return nil, err
+		}
+		_ = u.cache.cache.Set(ctx, key, data, u.cache.ttl)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeCache[T](data)
+}
+
+// cacheable 当前是否可以使用缓存
+func (u *Util[T]) cacheable() bool {
+	if u.cache == nil || IsForcePrimary(u.ctx) {
+		return false
+	}
+	_, inTx := TxFromContext(u.ctx, u.DB)
+	return !inTx
+}
+
+// cacheContext 缓存操作使用的上下文
+func (u *Util[T]) cacheContext() context.Context {
+	if u.ctx == nil {
+		return context.Background()
+	}
+	return u.ctx
+}
+
+// cacheKey 生成主键对应的缓存key
+func (u *Util[T]) cacheKey(id interface{}) string {
+	table := ""
+	if sch, err := u.schema(); err == nil {
+		table = sch.Table
+	}
+	return fmt.Sprintf("%s%s:%v", CacheKeyPrefix, table, id)
+}
+
+// sameTenant 缓存的记录是否属于上下文中的租户
+func (u *Util[T]) sameTenant(model *T) bool {
+	field := u.tenantField()
+	if field == nil || isSkipTenant(u.ctx) {
+		return true
+	}
+	tenantID, ok := TenantFromContext(u.ctx)
+	if !ok {
+		return false
+	}
+	value, _ := field.ValueOf(context.Background(), reflect.ValueOf(model).Elem())
+	return fmt.Sprint(value) == fmt.Sprint(tenantID)
+}
+
+// evictCache 删除记录对应的缓存 models 为 *T 或 []T
+// 删除失败时依赖缓存过期
+func (u *Util[T]) evictCache(models interface{}) {
+	if u.cache == nil {
+		return
+	}
+	sch, err := u.schema()
+	if err != nil || sch.PrioritizedPrimaryField == nil {
+		return
+	}
+	ctx := context.Background()
+	rv := reflect.Indirect(reflect.ValueOf(models))
+	rows := []reflect.Value{rv}
+	if rv.Kind() == reflect.Slice {
+		rows = make([]reflect.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, rv.Index(i))
+		}
+	}
+	ids := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		if id, zero := sch.PrioritizedPrimaryField.ValueOf(ctx, row); !zero {
+			ids = append(ids, id)
+		}
+	}
+	u.evictCacheIds(ids...)
+}
+
+// evictCacheIds 根据主键删除缓存 上下文中有事务时在事务提交后删除
+func (u *Util[T]) evictCacheIds(ids ...interface{}) {
+	if u.cache == nil || len(ids) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, u.cacheKey(id))
+	}
+	// 事务提交后再删除 避免提交前被其他请求重新缓存旧数据
+	ctx := context.WithoutCancel(u.cacheContext())
+	AfterCommit(u.ctx, u.DB, func() {
+		_ = u.cache.cache.Delete(ctx, keys...)
+	})
+}
+
+// evictAfter 写操作成功后删除缓存
+func (u *Util[T]) evictAfter(models interface{}, err error) error {
+	if err == nil {
+		u.evictCache(models)
+	}
+	return err
+}
+
+// encodeCache 序列化缓存的记录 使用gob以保留 json:"-" 的字段
+func encodeCache[T any](model *T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(model); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCache 反序列化缓存的记录
+func decodeCache[T any](data []byte) (*T, error) {
+	model := new(T)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
@@ -0,0 +1,95 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type cacheItem struct {
+	ID   uint
+	Name string
+}
+
+// cached 缓存中是否存在主键对应的记录
+func cached[T any](u *Util[T], id interface{}) bool {
+	_, ok, _ := u.cache.cache.Get(context.Background(), u.cacheKey(id))
+	return ok
+}
+
+func TestCacheEvictAfterCommit(t *testing.T) {
+	db := openTestDB(t, &cacheItem{})
+	if err := db.Create(&cacheItem{ID: 1, Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	u := NewUtil[cacheItem](db)
+	u.SetCache(NewLRUCache(10), time.Minute)
+	if _, err := u.GetById(1); err != nil {
+		t.Fatal(err)
+	}
+	if !cached(u, 1) {
+		t.Fatal("GetById 后应写入缓存")
+	}
+
+	err := WithTx(context.Background(), db, func(ctx context.Context) error {
+		if err := u.WithContext(ctx).UpdateOne(&cacheItem{ID: 1, Name: "b"}); err != nil {
+			return err
+		}
+		if !cached(u, 1) {
+			t.Error("事务提交前不应删除缓存")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached(u, 1) {
+		t.Fatal("事务提交后应删除缓存")
+	}
+	item, err := u.GetById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Name != "b" {
+		t.Fatalf("got %q, want b", item.Name)
+	}
+}
+
+func TestCacheKeptOnRollback(t *testing.T) {
+	db := openTestDB(t, &cacheItem{})
+	if err := db.Create(&cacheItem{ID: 1, Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	u := NewUtil[cacheItem](db)
+	u.SetCache(NewLRUCache(10), time.Minute)
+	if _, err := u.GetById(1); err != nil {
+		t.Fatal(err)
+	}
+
+	rollback := errors.New("rollback")
+	err := WithTx(context.Background(), db, func(ctx context.Context) error {
+		// 嵌套事务提交 外层事务回滚
+		err := WithTx(ctx, db, func(ctx context.Context) error {
+			return u.WithContext(ctx).UpdateOne(&cacheItem{ID: 1, Name: "b"})
+		})
+		if err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("got %v, want rollback", err)
+	}
+	if !cached(u, 1) {
+		t.Fatal("事务回滚时不应删除缓存")
+	}
+}
+
+func TestAfterCommitWithoutTx(t *testing.T) {
+	called := false
+	AfterCommit(context.Background(), nil, func() { called = true })
+	if !called {
+		t.Fatal("没有事务时应立即执行")
+	}
+}
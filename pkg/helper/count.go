@@ -0,0 +1,135 @@
+package helper
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// CountMode 分页查询的总数统计方式
+type CountMode int
+
+const (
+	CountExact    CountMode = iota // 精确统计(默认)
+	CountSkip                      // 不统计总数 通过多查一条判断是否还有数据
+	CountEstimate                  // 无筛选条件时使用数据库的统计信息估算 否则精确统计
+	CountCapped                    // 最多统计 CountCap 条 超出时 Total 为 CountCap
+	CountParallel                  // 与查询并发执行精确统计 事务中退化为顺序执行
+)
+
+// findPage 按统计方式执行分页查询 db 为已设置模型 关联和查询列的查询
+func findPage[R any](db *gorm.DB, request *PageRequest, list *PageList[R]) (err error) {
+	request.normalize()
+	// 使用新会话 保证查询和统计互不影响
+	db = db.Session(&gorm.Session{})
+	list.Page = request.Page
+	list.PageSize = request.PageSize
+	list.TotalExact = true
+
+	switch request.CountMode {
+	case CountSkip:
+		// 多查一条用于判断是否还有数据
+		err = db.Scopes(Paginate(request), func(db *gorm.DB) *gorm.DB {
+			return db.Limit(request.PageSize + 1)
+		}).Find(&list.Data).Error
+		if len(list.Data) > request.PageSize {
+			list.HasMore = true
+			list.Data = list.Data[:request.PageSize]
+		}
+		list.TotalExact = false
+		return err
+	case CountParallel:
+		if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); !inTx {
+			countDB := db.Session(&gorm.Session{})
+			done := make(chan error, 1)
+			go func() {
+				done <- countDB.Scopes(request.buildWhere).Count(&list.Total).Error
+			}()
+			err = db.Scopes(Paginate(request)).Find(&list.Data).Error
+			if countErr := <-done; err == nil {
+				err = countErr
+			}
+			break
+		}
+		err = db.Scopes(Paginate(request)).Find(&list.Data).Offset(-1).Count(&list.Total).Error
+	case CountCapped:
+		if err = db.Scopes(Paginate(request)).Find(&list.Data).Error; err != nil {
+			return err
+		}
+		if request.CountCap <= 0 {
+			err = db.Scopes(request.buildWhere).Count(&list.Total).Error
+			break
+		}
+		// 子查询需要非nil的模型实例才能生成SQL
+		stmt := &gorm.Statement{DB: db}
+		if err = stmt.Parse(db.Statement.Model); err != nil {
+			return err
+		}
+		sub := db.Model(reflect.New(stmt.Schema.ModelType).Interface()).
+			Scopes(request.buildWhere).Select("1").Limit(int(request.CountCap) + 1)
+		err = db.Session(&gorm.Session{NewDB: true}).Table("(?) AS capped", sub).Count(&list.Total).Error
+		if list.Total > request.CountCap {
+			list.Total = request.CountCap
+			list.TotalExact = false
+			list.HasMore = true
+			return err
+		}
+	case CountEstimate:
+		if err = db.Scopes(Paginate(request)).Find(&list.Data).Error; err != nil {
+			return err
+		}
+		if request.hasConditions() {
+			err = db.Scopes(request.buildWhere).Count(&list.Total).Error
+			break
+		}
+		if total, ok := estimateCount(db); ok {
+			list.Total = total
+			list.TotalExact = false
+		} else {
+			err = db.Scopes(request.buildWhere).Count(&list.Total).Error
+		}
+	default:
+		err = db.Scopes(Paginate(request)).Find(&list.Data).Offset(-1).Count(&list.Total).Error
+	}
+	list.HasMore = int64(request.Page*request.PageSize) < list.Total
+	return err
+}
+
+// hasConditions 是否有筛选条件 设置了数据权限规则也视为有筛选条件
+func (p *PageRequest) hasConditions() bool {
+	return len(p.Where) > 0 || len(p.OrWhere) > 0 || len(p.filters) > 0 || p.dataScope != nil
+}
+
+// dataScopeSettingKey 查询设置了模型数据权限规则的标记
+const dataScopeSettingKey = "helper:data_scope"
+
+// estimateCount 使用数据库的统计信息估算表的行数 仅支持 MySQL 和 PostgreSQL
+func estimateCount(db *gorm.DB) (int64, bool) {
+	// 开启租户隔离时 估算的全表行数会包含其他租户的数据
+	if getTenantColumn() != "" {
+		return 0, false
+	}
+	// 设置了数据权限时 估算的全表行数会包含无权访问的数据
+	if _, ok := db.Get(dataScopeSettingKey); ok {
+		return 0, false
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(db.Statement.Model); err != nil {
+		return 0, false
+	}
+	var total int64
+	var err error
+	conn := db.Session(&gorm.Session{NewDB: true})
+	switch db.Dialector.Name() {
+	case "mysql":
+		err = conn.Raw("SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", stmt.Schema.Table).Scan(&total).Error
+	case "postgres":
+		err = conn.Raw("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)", stmt.Schema.Table).Scan(&total).Error
+	default:
+		return 0, false
+	}
+	if err != nil || total < 0 {
+		return 0, false
+	}
+	return total, true
+}
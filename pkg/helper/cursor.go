@@ -0,0 +1,194 @@
+package helper
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	cursorNext = "next" // 向后翻页
+	cursorPrev = "prev" // 向前翻页
+)
+
+// cursorToken 游标内容 编码为base64后对外暴露 对调用方是不透明的
+type cursorToken struct {
+	Direction string            `json:"d"`
+	Values    []json.RawMessage `json:"v"`
+}
+
+// cursorKey 游标分页的排序键
+type cursorKey struct {
+	field *schema.Field
+	desc  bool
+}
+
+// GetCursorList 游标(keyset)分页获取多条记录 不统计总数
+// 排序键通过 PageRequest.CursorSort 设置 默认按主键正序
+func (u *Util[T]) GetCursorList(request *PageRequest) (*CursorPageList[T], error) {
+	request.normalize()
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := resolveCursorKeys(sch, request.cursorKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := u.listDB(request.relations)
+	if err != nil {
+		return nil, err
+	}
+	db = request.buildWhere(db)
+
+	backward := false
+	if request.Cursor != "" {
+		token, values, err := decodeCursor(request.Cursor, keys)
+		if err != nil {
+			return nil, err
+		}
+		backward = token.Direction == cursorPrev
+		db = db.Where(seekCondition(keys, values, backward))
+	}
+	for _, key := range keys {
+		db = db.Order(clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: key.field.DBName},
+			Desc:   key.desc != backward,
+		})
+	}
+
+	list := NewCursorPageList[T]()
+	list.Data = make([]T, 0)
+	list.PageSize = request.PageSize
+	// 多查一条用于判断是否还有数据
+	if err = db.Limit(request.PageSize + 1).Find(&list.Data).Error; err != nil {
+		return nil, err
+	}
+	if len(list.Data) > request.PageSize {
+		list.HasMore = true
+		list.Data = list.Data[:request.PageSize]
+	}
+	if backward {
+		for i, j := 0, len(list.Data)-1; i < j; i, j = i+1, j-1 {
+			list.Data[i], list.Data[j] = list.Data[j], list.Data[i]
+		}
+	}
+	if len(list.Data) == 0 {
+		return list, nil
+	}
+
+	ctx := db.Statement.Context
+	first := reflect.ValueOf(&list.Data[0]).Elem()
+	last := reflect.ValueOf(&list.Data[len(list.Data)-1]).Elem()
+	// 向后翻页: 有更多数据才返回next 带了游标说明前面还有数据
+	// 向前翻页: 有更多数据才返回prev 后面一定还有数据
+	if (!backward && list.HasMore) || backward {
+		if list.NextCursor, err = encodeCursor(ctx, cursorNext, keys, last); err != nil {
+			return nil, err
+		}
+	}
+	if (backward && list.HasMore) || (!backward && request.Cursor != "") {
+		if list.PrevCursor, err = encodeCursor(ctx, cursorPrev, keys, first); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// resolveCursorKeys 将排序键解析为模型字段 未包含主键时追加主键
+func resolveCursorKeys(sch *schema.Schema, names []string) ([]cursorKey, error) {
+	keys := make([]cursorKey, 0, len(names)+1)
+	hasPrimary := false
+	for _, name := range names {
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		field := sch.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, NewErrorModel(ERROR, "不支持的游标排序字段: "+name, nil, http.StatusBadRequest)
+		}
+		if field.PrimaryKey {
+			hasPrimary = true
+		}
+		keys = append(keys, cursorKey{field: field, desc: desc})
+	}
+	if !hasPrimary {
+		if sch.PrioritizedPrimaryField == nil {
+			return nil, NewErrorModel(ERROR, "游标分页需要模型包含主键", nil, http.StatusInternalServerError)
+		}
+		desc := len(keys) > 0 && keys[len(keys)-1].desc
+		keys = append(keys, cursorKey{field: sch.PrioritizedPrimaryField, desc: desc})
+	}
+	return keys, nil
+}
+
+// seekCondition 生成 (k1 > v1) OR (k1 = v1 AND k2 > v2) ... 形式的条件
+func seekCondition(keys []cursorKey, values []interface{}, backward bool) clause.Expression {
+	ors := make([]clause.Expression, 0, len(keys))
+	for i, key := range keys {
+		ands := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{
+				Column: clause.Column{Table: clause.CurrentTable, Name: keys[j].field.DBName},
+				Value:  values[j],
+			})
+		}
+		column := clause.Column{Table: clause.CurrentTable, Name: key.field.DBName}
+		if key.desc != backward {
+			ands = append(ands, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			ands = append(ands, clause.Gt{Column: column, Value: values[i]})
+		}
+		ors = append(ors, clause.And(ands...))
+	}
+	return clause.Or(ors...)
+}
+
+// encodeCursor 根据记录的排序键生成游标
+func encodeCursor(ctx context.Context, direction string, keys []cursorKey, row reflect.Value) (string, error) {
+	token := cursorToken{Direction: direction, Values: make([]json.RawMessage, 0, len(keys))}
+	for _, key := range keys {
+		value, _ := key.field.ValueOf(ctx, row)
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		token.Values = append(token.Values, raw)
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解析游标 并按字段类型还原排序键的值
+func decodeCursor(cursor string, keys []cursorKey) (*cursorToken, []interface{}, error) {
+	invalid := NewErrorModel(ERROR, "无效的游标", nil, http.StatusBadRequest)
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, nil, invalid
+	}
+	token := &cursorToken{}
+	if err = json.Unmarshal(data, token); err != nil {
+		return nil, nil, invalid
+	}
+	if (token.Direction != cursorNext && token.Direction != cursorPrev) || len(token.Values) != len(keys) {
+		return nil, nil, invalid
+	}
+	values := make([]interface{}, 0, len(keys))
+	for i, key := range keys {
+		value := reflect.New(key.field.FieldType)
+		if err = json.Unmarshal(token.Values[i], value.Interface()); err != nil {
+			return nil, nil, invalid
+		}
+		values = append(values, value.Elem().Interface())
+	}
+	return token, values, nil
+}
@@ -0,0 +1,103 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDataScopeRequired 列表查询配置了数据权限 但上下文中缺少当前用户的数据权限
+var ErrDataScopeRequired = NewErrorModel(ERROR, "缺少数据权限", nil, http.StatusForbidden)
+
+// DataScopeKind 数据权限范围
+type DataScopeKind int
+
+const (
+	DataScopeAll  DataScopeKind = iota // 全部数据
+	DataScopeDept                      // 部门数据 本部门或本部门及下级部门 由 DeptIDs 决定
+	DataScopeSelf                      // 仅本人数据
+)
+
+// DataScope 当前用户的数据权限
+type DataScope struct {
+	Kind    DataScopeKind
+	UserID  interface{}   // 当前用户
+	DeptIDs []interface{} // 可访问的部门 需要包含下级部门时由调用方展开
+}
+
+// DataScopeRule 模型的数据权限规则
+type DataScopeRule struct {
+	UserColumn string // 数据所属用户的列 例如 created_by
+	DeptColumn string // 数据所属部门的列 例如 dept_id
+	// Custom 自定义数据权限条件 设置后忽略列配置
+	Custom func(db *gorm.DB, scope DataScope) *gorm.DB
+}
+
+// dataScopeContextKey 数据权限在上下文中的key
+type dataScopeContextKey struct{}
+
+// WithDataScope 将当前用户的数据权限保存到上下文 一般在 BaseCtxFunc 中根据登录信息设置
+func WithDataScope(ctx context.Context, scope DataScope) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, dataScopeContextKey{}, scope)
+}
+
+// DataScopeFromContext 获取上下文中的数据权限
+func DataScopeFromContext(ctx context.Context) (DataScope, bool) {
+	if ctx == nil {
+		return DataScope{}, false
+	}
+	scope, ok := ctx.Value(dataScopeContextKey{}).(DataScope)
+	return scope, ok
+}
+
+// Scope 根据查询上下文中的数据权限添加条件 上下文中没有数据权限时返回 ErrDataScopeRequired
+func (r DataScopeRule) Scope(db *gorm.DB) *gorm.DB {
+	scope, ok := DataScopeFromContext(db.Statement.Context)
+	if !ok {
+		_ = db.AddError(ErrDataScopeRequired)
+		return db
+	}
+	if r.Custom != nil {
+		return r.Custom(db, scope)
+	}
+	switch scope.Kind {
+	case DataScopeAll:
+		return db
+	case DataScopeDept:
+		if r.DeptColumn != "" {
+			return db.Where(clause.IN{
+				Column: clause.Column{Table: clause.CurrentTable, Name: r.DeptColumn},
+				Values: scope.DeptIDs,
+			})
+		}
+	case DataScopeSelf:
+		if r.UserColumn != "" {
+			return db.Where(clause.Eq{
+				Column: clause.Column{Table: clause.CurrentTable, Name: r.UserColumn},
+				Value:  scope.UserID,
+			})
+		}
+	}
+	_ = db.AddError(errors.New("数据权限规则未配置对应的列"))
+	return db
+}
+
+// WithDataScope 设置列表查询的数据权限规则 Paginate 会按查询上下文中的数据权限过滤
+// 使用 Util[T].SetDataScope 时无需再设置
+func (p *PageRequest) WithDataScope(rule DataScopeRule) *PageRequest {
+	p.dataScope = &rule
+	return p
+}
+
+// SetDataScope 设置模型的数据权限规则 GetList 等列表查询会按上下文中的数据权限过滤
+//
+//	dao.SetDataScope(helper.DataScopeRule{UserColumn: "created_by", DeptColumn: "dept_id"})
+func (u *Util[T]) SetDataScope(rule DataScopeRule) {
+	u.dataScope = &rule
+}
@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// setupDataScope 返回只能访问用户1数据的 Util
+func setupDataScope(t *testing.T) *Util[isolatedItem] {
+	t.Helper()
+	ctx := WithDataScope(context.Background(), DataScope{Kind: DataScopeSelf, UserID: uint(1)})
+	return NewUtil[isolatedItem](seedIsolated(t)).WithContext(ctx)
+}
+
+func TestDataScopeOrWhere(t *testing.T) {
+	u := setupDataScope(t)
+	u.SetDataScope(DataScopeRule{UserColumn: "created_by"})
+	list, err := u.GetList(orWhereRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOwned(t, list.Data)
+	if list.Total != 1 || len(list.Data) != 1 {
+		t.Fatalf("期望1条记录 实际 total=%d len=%d", list.Total, len(list.Data))
+	}
+}
+
+func TestPageRequestDataScopeOrWhere(t *testing.T) {
+	u := setupDataScope(t)
+	list, err := u.GetList(orWhereRequest().WithDataScope(DataScopeRule{UserColumn: "created_by"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOwned(t, list.Data)
+	if list.Total != 1 {
+		t.Fatalf("期望1条记录 实际 total=%d", list.Total)
+	}
+}
+
+func TestDataScopeCountEstimate(t *testing.T) {
+	u := setupDataScope(t)
+	if !NewPageReq().WithDataScope(DataScopeRule{UserColumn: "created_by"}).hasConditions() {
+		t.Fatal("请求设置了数据权限时应精确统计")
+	}
+	u.SetDataScope(DataScopeRule{UserColumn: "created_by"})
+	db, err := u.listDB(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.Session(&gorm.Session{}).Get(dataScopeSettingKey); !ok {
+		t.Fatal("模型设置了数据权限时应精确统计")
+	}
+	request := NewPageReq()
+	request.CountMode = CountEstimate
+	list, err := u.GetList(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 2 || !list.TotalExact {
+		t.Fatalf("期望精确统计2条 实际 total=%d exact=%v", list.Total, list.TotalExact)
+	}
+}
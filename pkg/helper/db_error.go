@@ -0,0 +1,132 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// DBErrorKind 数据库错误分类
+type DBErrorKind int
+
+const (
+	DBErrorUnknown    DBErrorKind = iota // 未识别的错误
+	DBErrorNotFound                      // 记录不存在
+	DBErrorDuplicate                     // 唯一键冲突
+	DBErrorForeignKey                    // 外键约束
+	DBErrorRetryable                     // 死锁 锁等待超时 序列化失败等可重试的错误
+)
+
+// 默认的数据库错误 项目可在初始化时替换为自己的错误码
+var (
+	ErrDBNotFound   = NewErrorModel(ERROR, "记录不存在", nil, http.StatusNotFound)
+	ErrDBDuplicate  = NewErrorModel(ERROR, "数据已存在", nil, http.StatusConflict)
+	ErrDBForeignKey = NewErrorModel(ERROR, "关联数据不存在或仍被引用", nil, http.StatusUnprocessableEntity)
+	ErrDBRetryable  = NewErrorModel(ERROR, "系统繁忙，请稍后重试", nil, http.StatusServiceUnavailable)
+)
+
+// DBErrorTranslator 数据库错误翻译器 返回nil表示不处理 交给后续翻译器
+type DBErrorTranslator func(err error) *ErrorModel
+
+var (
+	dbErrorTranslators     []DBErrorTranslator
+	dbErrorTranslatorsLock sync.RWMutex
+)
+
+// dbErrorPatterns 各数据库驱动的错误信息特征 分别对应 MySQL PostgreSQL SQLite
+var dbErrorPatterns = []struct {
+	kind     DBErrorKind
+	patterns []string
+}{
+	{DBErrorDuplicate, []string{"Error 1062", "SQLSTATE 23505", "UNIQUE constraint failed"}},
+	{DBErrorForeignKey, []string{"Error 1451", "Error 1452", "SQLSTATE 23503", "FOREIGN KEY constraint failed"}},
+	{DBErrorRetryable, []string{"Error 1213", "Error 1205", "SQLSTATE 40001", "SQLSTATE 40P01", "database is locked"}},
+}
+
+// sqlStates 错误码对应的分类 用于实现了 SQLState() 的驱动错误
+var sqlStates = map[string]DBErrorKind{
+	"23505": DBErrorDuplicate,
+	"23503": DBErrorForeignKey,
+	"40001": DBErrorRetryable,
+	"40P01": DBErrorRetryable,
+}
+
+// RegisterDBErrorTranslator 注册自定义的数据库错误翻译器 先注册的优先 均未处理时使用默认规则
+func RegisterDBErrorTranslator(translator DBErrorTranslator) {
+	dbErrorTranslatorsLock.Lock()
+	defer dbErrorTranslatorsLock.Unlock()
+	dbErrorTranslators = append(dbErrorTranslators, translator)
+}
+
+// ClassifyDBError 识别数据库错误的分类
+func ClassifyDBError(err error) DBErrorKind {
+	if err == nil {
+		return DBErrorUnknown
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return DBErrorNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return DBErrorDuplicate
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return DBErrorForeignKey
+	}
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		if kind, ok := sqlStates[stateErr.SQLState()]; ok {
+			return kind
+		}
+	}
+	message := err.Error()
+	for _, item := range dbErrorPatterns {
+		for _, pattern := range item.patterns {
+			if strings.Contains(message, pattern) {
+				return item.kind
+			}
+		}
+	}
+	return DBErrorUnknown
+}
+
+// IsRetryableDBError 是否为可重试的数据库错误
+func IsRetryableDBError(err error) bool {
+	return ClassifyDBError(err) == DBErrorRetryable
+}
+
+// TranslateDBError 将数据库错误翻译为 ErrorModel 无法识别时返回nil
+// 返回的 ErrorModel 携带原始错误 仍可使用 errors.Is(err, gorm.ErrRecordNotFound) 判断
+func TranslateDBError(err error) *ErrorModel {
+	if err == nil {
+		return nil
+	}
+	dbErrorTranslatorsLock.RLock()
+	translators := dbErrorTranslators
+	dbErrorTranslatorsLock.RUnlock()
+	for _, translator := range translators {
+		if errModel := translator(err); errModel != nil {
+			return errModel.Wrap(err)
+		}
+	}
+	switch ClassifyDBError(err) {
+	case DBErrorNotFound:
+		return ErrDBNotFound.Wrap(err)
+	case DBErrorDuplicate:
+		return ErrDBDuplicate.Wrap(err)
+	case DBErrorForeignKey:
+		return ErrDBForeignKey.Wrap(err)
+	case DBErrorRetryable:
+		return ErrDBRetryable.Wrap(err)
+	}
+	return nil
+}
+
+// wrapDBError 可识别的数据库错误翻译为 ErrorModel 否则原样返回
+func wrapDBError(err error) error {
+	if errModel := TranslateDBError(err); errModel != nil {
+		return errModel
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// DefaultDBName 默认数据库连接名
+const DefaultDBName = "default"
+
+// DBCluster 一组主从数据库连接 写操作使用主库 读操作轮询使用从库
+type DBCluster struct {
+	Primary  *gorm.DB
+	Replicas []*gorm.DB
+
+	next uint64 // 下一个从库的序号
+}
+
+// NewDBCluster 创建主从数据库连接 没有从库时读写都使用主库
+func NewDBCluster(primary *gorm.DB, replicas ...*gorm.DB) *DBCluster {
+	return &DBCluster{
+		Primary:  primary,
+		Replicas: replicas,
+	}
+}
+
+// Reader 获取读库 轮询从库 没有从库时返回主库
+func (c *DBCluster) Reader() *gorm.DB {
+	if len(c.Replicas) == 0 {
+		return c.Primary
+	}
+	n := atomic.AddUint64(&c.next, 1)
+	return c.Replicas[(n-1)%uint64(len(c.Replicas))]
+}
+
+var (
+	dbRegistry     = make(map[string]*DBCluster)
+	dbRegistryLock sync.RWMutex
+)
+
+// RegisterDB 注册命名的数据库连接 同名连接会被覆盖
+//
+//	helper.RegisterDB(helper.DefaultDBName, primary, replica1, replica2)
+//	helper.RegisterDB("log", logDB)
+func RegisterDB(name string, primary *gorm.DB, replicas ...*gorm.DB) *DBCluster {
+	cluster := NewDBCluster(primary, replicas...)
+	dbRegistryLock.Lock()
+	defer dbRegistryLock.Unlock()
+	dbRegistry[name] = cluster
+	return cluster
+}
+
+// GetDBCluster 获取命名的数据库连接
+func GetDBCluster(name string) (*DBCluster, bool) {
+	dbRegistryLock.RLock()
+	defer dbRegistryLock.RUnlock()
+	cluster, ok := dbRegistry[name]
+	return cluster, ok
+}
+
+// NewUtilWithCluster 使用主从数据库连接创建 Util 读操作使用从库 写操作使用主库
+func NewUtilWithCluster[T interface{}](cluster *DBCluster) *Util[T] {
+	u := NewUtil[T](cluster.Primary)
+	u.cluster = cluster
+	return u
+}
+
+// NewUtilByName 使用注册的数据库连接创建 Util 连接未注册时panic
+func NewUtilByName[T interface{}](name string) *Util[T] {
+	cluster, ok := GetDBCluster(name)
+	if !ok {
+		panic(fmt.Sprintf("数据库连接%s未注册", name))
+	}
+	return NewUtilWithCluster[T](cluster)
+}
+
+// forcePrimaryContextKey 强制读主库在上下文中的key
+type forcePrimaryContextKey struct{}
+
+// WithPrimary 返回强制读主库的上下文 用于写入后立即读取 避免主从延迟读到旧数据
+func WithPrimary(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, forcePrimaryContextKey{}, true)
+}
+
+// IsForcePrimary 上下文是否强制读主库
+func IsForcePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	force, _ := ctx.Value(forcePrimaryContextKey{}).(bool)
+	return force
+}
@@ -0,0 +1,36 @@
+package helper
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type replicaItem struct {
+	ID   uint
+	Name string
+}
+
+func TestSetDBAppliesToReplicas(t *testing.T) {
+	primary := openTestDB(t, &replicaItem{})
+	replica := openNamedTestDB(t, "_replica", &replicaItem{})
+	items := []replicaItem{{Name: "a"}, {Name: "b"}}
+	if err := replica.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	cluster := NewDBCluster(primary, replica)
+	u := NewUtilWithCluster[replicaItem](cluster)
+	u.SetDB(func(db *gorm.DB) *gorm.DB {
+		return db.Where("name = ?", "a").Session(&gorm.Session{})
+	})
+	list, err := u.GetList(NewPageReq())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 1 || list.Data[0].Name != "a" {
+		t.Fatalf("从库查询应使用 SetDB 的条件 实际 %+v", list.Data)
+	}
+	if cluster.Replicas[0] != replica {
+		t.Fatal("不应修改共用的连接")
+	}
+}
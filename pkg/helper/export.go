@@ -0,0 +1,277 @@
+package helper
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// SheetFormat 导入导出的文件格式
+type SheetFormat string
+
+const (
+	SheetCSV  SheetFormat = "csv"
+	SheetXLSX SheetFormat = "xlsx"
+)
+
+// SheetTimeLayout 导入导出时间的格式
+var SheetTimeLayout = "2006-01-02 15:04:05"
+
+// sheetName XLSX 使用的工作表
+const sheetName = "Sheet1"
+
+// sheetColumn 导入导出的列
+type sheetColumn struct {
+	header string
+	index  []int
+}
+
+// sheetColumns 解析结构体的列 表头取 excel 标签 例如 `excel:"姓名"` 为"-"时忽略
+// 没有字段设置 excel 标签时使用 json 标签或字段名 嵌入的结构体会展开
+func sheetColumns(t reflect.Type) []sheetColumn {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	tagged := make([]sheetColumn, 0)
+	untagged := make([]sheetColumn, 0)
+	collectSheetColumns(t, nil, &tagged, &untagged)
+	if len(tagged) > 0 {
+		return tagged
+	}
+	return untagged
+}
+
+// collectSheetColumns 递归收集结构体的列
+func collectSheetColumns(t reflect.Type, parent []int, tagged, untagged *[]sheetColumn) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		index := append(append([]int{}, parent...), i)
+		tag, hasTag := field.Tag.Lookup("excel")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			collectSheetColumns(field.Type, index, tagged, untagged)
+			continue
+		}
+		if hasTag {
+			*tagged = append(*tagged, sheetColumn{header: tag, index: index})
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		*untagged = append(*untagged, sheetColumn{header: name, index: index})
+	}
+}
+
+// sheetWriter 按行写入文件
+type sheetWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// csvSheetWriter CSV 写入 每行直接写入响应
+type csvSheetWriter struct {
+	w *csv.Writer
+}
+
+// WriteRow 写入一行 字符串按 escapeFormula 转义 防止打开CSV时被当作公式执行
+// XLSX 的字符串单元格不会被当作公式 无需转义
+func (s *csvSheetWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			record[i] = escapeFormula(str)
+			continue
+		}
+		record[i] = fmt.Sprint(value)
+	}
+	return s.w.Write(record)
+}
+
+// escapeFormula 以 = + - @ 制表符 回车开头的字符串前加单引号
+func escapeFormula(str string) string {
+	if str != "" && strings.ContainsRune("=+-@\t\r", rune(str[0])) {
+		return "'" + str
+	}
+	return str
+}
+
+func (s *csvSheetWriter) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// xlsxSheetWriter XLSX 流式写入 数据较多时暂存到临时文件 结束时写入响应
+type xlsxSheetWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	out    http.ResponseWriter
+	row    int
+}
+
+func (s *xlsxSheetWriter) WriteRow(values []interface{}) error {
+	s.row++
+	cell, err := excelize.CoordinatesToCellName(1, s.row)
+	if err != nil {
+		return err
+	}
+	return s.stream.SetRow(cell, values)
+}
+
+func (s *xlsxSheetWriter) Close() error {
+	defer s.file.Close()
+	if err := s.stream.Flush(); err != nil {
+		return err
+	}
+	return s.file.Write(s.out)
+}
+
+// newSheetWriter 设置下载的响应头并创建写入器
+func newSheetWriter(c *gin.Context, format SheetFormat, filename string) (sheetWriter, error) {
+	if !strings.HasSuffix(strings.ToLower(filename), "."+string(format)) {
+		filename += "." + string(format)
+	}
+	switch format {
+	case SheetCSV:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	case SheetXLSX:
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	default:
+		return nil, NewErrorModel(ERROR, "不支持的文件格式: "+string(format), nil, http.StatusBadRequest)
+	}
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(filename))
+	c.Status(http.StatusOK)
+
+	if format == SheetCSV {
+		// 写入BOM 避免Excel打开中文乱码
+		if _, err := c.Writer.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &csvSheetWriter{w: csv.NewWriter(c.Writer)}, nil
+	}
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(sheetName)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &xlsxSheetWriter{file: file, stream: stream, out: c.Writer}, nil
+}
+
+// sheetCell 转换单元格的值 时间按 SheetTimeLayout 格式化 空指针为空字符串
+func sheetCell(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	v := value.Interface()
+	switch t := v.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(SheetTimeLayout)
+	case driver.Valuer:
+		// sql.NullXXX gorm.DeletedAt 等类型
+		dv, err := t.Value()
+		if err != nil || dv == nil {
+			return ""
+		}
+		if tt, ok := dv.(time.Time); ok {
+			return tt.Format(SheetTimeLayout)
+		}
+		return dv
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			return string(value.Bytes())
+		}
+		return fmt.Sprint(v)
+	}
+	return v
+}
+
+// Export 按 PageRequest 的条件分批查询 以CSV或XLSX格式写入响应 表头取 excel 标签
+// 分页和排序参数会被忽略 按主键顺序导出 写入响应前出错时返回错误 可继续返回JSON错误
+//
+//	func (c *UserController) Export(ctx *gin.Context) {
+//		if err := c.dao.WithContext(ctx.Request.Context()).Export(ctx, page, helper.SheetXLSX, "用户列表"); err != nil {
+//			helper.NewGinActionImpl(ctx).Error(err)
+//		}
+//	}
+func (u *Util[T]) Export(c *gin.Context, request *PageRequest, format SheetFormat, filename string) error {
+	if format != SheetCSV && format != SheetXLSX {
+		return NewErrorModel(ERROR, "不支持的文件格式: "+string(format), nil, http.StatusBadRequest)
+	}
+	columns := sheetColumns(reflect.TypeOf(new(T)))
+	var writer sheetWriter
+	start := func() error {
+		var err error
+		if writer, err = newSheetWriter(c, format, filename); err != nil {
+			return err
+		}
+		headers := make([]interface{}, 0, len(columns))
+		for _, column := range columns {
+			headers = append(headers, column.header)
+		}
+		return writer.WriteRow(headers)
+	}
+
+	err := u.FindInBatches(request, DefaultBatchSize, func(batch []T, _ BatchProgress) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i := range batch {
+			row := reflect.ValueOf(&batch[i]).Elem()
+			values := make([]interface{}, 0, len(columns))
+			for _, column := range columns {
+				values = append(values, sheetCell(row.FieldByIndex(column.index)))
+			}
+			if err := writer.WriteRow(values); err != nil {
+				return err
+			}
+		}
+		if cw, ok := writer.(*csvSheetWriter); ok {
+			cw.w.Flush()
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if xw, ok := writer.(*xlsxSheetWriter); ok {
+			_ = xw.file.Close()
+		}
+		return err
+	}
+	// 没有数据时只导出表头
+	if writer == nil {
+		if err = start(); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
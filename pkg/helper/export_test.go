@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+type exportItem struct {
+	ID   uint   `excel:"编号"`
+	Name string `excel:"名称"`
+}
+
+// exportRows 导出所有记录 返回数据行的名称列
+func exportRows(t *testing.T, format SheetFormat) []string {
+	t.Helper()
+	db := openTestDB(t, &exportItem{})
+	items := []exportItem{{Name: "=HYPERLINK(\"http://x\")"}, {Name: "+1"}, {Name: "-1"}, {Name: "@SUM(A1)"}, {Name: "normal"}}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if err := NewUtil[exportItem](db).Export(c, NewPageReq(), format, "items"); err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	var err error
+	if format == SheetCSV {
+		rows, err = csv.NewReader(bytes.NewReader(bytes.TrimPrefix(w.Body.Bytes(), []byte("\xEF\xBB\xBF")))).ReadAll()
+	} else {
+		var file *excelize.File
+		if file, err = excelize.OpenReader(w.Body); err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		rows, err = file.GetRows(sheetName)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(rows))
+	for _, row := range rows[1:] {
+		names = append(names, row[1])
+	}
+	return names
+}
+
+func TestExportEscapeFormula(t *testing.T) {
+	tests := []struct {
+		format SheetFormat
+		want   []string
+	}{
+		{SheetCSV, []string{"'=HYPERLINK(\"http://x\")", "'+1", "'-1", "'@SUM(A1)", "normal"}},
+		// XLSX 的字符串不会被当作公式 保留原始值
+		{SheetXLSX, []string{"=HYPERLINK(\"http://x\")", "+1", "-1", "@SUM(A1)", "normal"}},
+	}
+	for _, tt := range tests {
+		want := tt.want
+		t.Run(string(tt.format), func(t *testing.T) {
+			got := exportRows(t, tt.format)
+			if len(got) != len(want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("got %q, want %q", got[i], want[i])
+				}
+			}
+		})
+	}
+}
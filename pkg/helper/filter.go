@@ -0,0 +1,245 @@
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// FilterOp 筛选操作符
+type FilterOp string
+
+const (
+	FilterEq      FilterOp = "eq"      // 等于
+	FilterNe      FilterOp = "ne"      // 不等于
+	FilterIn      FilterOp = "in"      // 包含 值为切片或逗号分隔的字符串
+	FilterLike    FilterOp = "like"    // 模糊匹配
+	FilterBetween FilterOp = "between" // 区间 值为两个元素的切片或逗号分隔的字符串
+	FilterGt      FilterOp = "gt"      // 大于
+	FilterGte     FilterOp = "gte"     // 大于等于
+	FilterLt      FilterOp = "lt"      // 小于
+	FilterLte     FilterOp = "lte"     // 小于等于
+	FilterIsNull  FilterOp = "null"    // 为空 值为true时IS NULL false时IS NOT NULL
+)
+
+// Filter 客户端传入的筛选条件
+type Filter struct {
+	Field string      `json:"field" form:"field"`
+	Op    FilterOp    `json:"op" form:"op"`
+	Value interface{} `json:"value" form:"value"`
+}
+
+// FilterRule 字段的筛选规则
+type FilterRule struct {
+	Column string     // 数据库列名
+	Ops    []FilterOp // 允许的操作符 为空时只允许eq
+}
+
+// FilterWhitelist 允许筛选的字段白名单 key为对外暴露的字段名
+//
+//	whitelist := helper.FilterWhitelist{
+//		"name":   {Column: "name", Ops: []helper.FilterOp{helper.FilterEq, helper.FilterLike}},
+//		"status": {Column: "status", Ops: []helper.FilterOp{helper.FilterIn}},
+//	}
+type FilterWhitelist map[string]FilterRule
+
+// Apply 校验客户端的筛选条件并追加到分页请求 字段或操作符不在白名单内时返回400错误
+func (w FilterWhitelist) Apply(p *PageRequest, filters ...Filter) error {
+	for _, f := range filters {
+		rule, ok := w[f.Field]
+		if !ok {
+			return NewErrorModel(ERROR, "不支持的筛选字段: "+f.Field, nil, http.StatusBadRequest)
+		}
+		op := f.Op
+		if op == "" {
+			op = FilterEq
+		}
+		if !rule.allow(op) {
+			return NewErrorModel(ERROR, fmt.Sprintf("字段%s不支持筛选操作: %s", f.Field, op), nil, http.StatusBadRequest)
+		}
+		if err := p.AddFilter(rule.Column, op, f.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allow 判断操作符是否允许
+func (r FilterRule) allow(op FilterOp) bool {
+	if len(r.Ops) == 0 {
+		return op == FilterEq
+	}
+	for _, o := range r.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFilter 追加一个筛选条件 列名会被转义 值作为参数传入
+func (p *PageRequest) AddFilter(column string, op FilterOp, value interface{}) error {
+	expr, err := buildFilter(column, op, value)
+	if err != nil {
+		return err
+	}
+	p.filters = append(p.filters, expr)
+	return nil
+}
+
+// BindFilter 根据请求结构体的filter标签生成筛选条件 零值字段会被忽略
+//
+//	type UserListRequest struct {
+//		helper.BaseRequest
+//		Name   string `form:"name" filter:"name,op=like"`
+//		Status []int  `form:"status" filter:"status,op=in"`
+//	}
+func (p *PageRequest) BindFilter(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("BindFilter 参数必须为结构体或结构体指针")
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		tag, ok := field.Tag.Lookup("filter")
+		if !ok {
+			// 递归处理嵌入的结构体
+			if field.Anonymous && field.IsExported() && value.Kind() == reflect.Struct {
+				if err := p.BindFilter(value.Interface()); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if tag == "-" || !field.IsExported() || value.IsZero() {
+			continue
+		}
+		column, op, err := parseFilterTag(field, tag)
+		if err != nil {
+			return err
+		}
+		for value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		if err = p.AddFilter(column, op, value.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseFilterTag 解析filter标签 格式为 "列名,op=操作符" 列名为空时使用字段名的蛇形命名
+func parseFilterTag(field reflect.StructField, tag string) (string, FilterOp, error) {
+	parts := strings.Split(tag, ",")
+	column := strings.TrimSpace(parts[0])
+	if column == "" {
+		column = schema.NamingStrategy{}.ColumnName("", field.Name)
+	}
+	op := FilterEq
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 && kv[0] == "op" {
+			op = FilterOp(kv[1])
+			continue
+		}
+		return "", "", fmt.Errorf("字段%s的filter标签无效: %s", field.Name, tag)
+	}
+	return column, op, nil
+}
+
+// buildFilter 将筛选条件转换为GORM表达式
+func buildFilter(column string, op FilterOp, value interface{}) (clause.Expression, error) {
+	col := tableColumn(column)
+	invalid := NewErrorModel(ERROR, "筛选条件"+column+"的值无效", nil, http.StatusBadRequest)
+	switch op {
+	case FilterEq:
+		return clause.Eq{Column: col, Value: value}, nil
+	case FilterNe:
+		return clause.Neq{Column: col, Value: value}, nil
+	case FilterGt:
+		return clause.Gt{Column: col, Value: value}, nil
+	case FilterGte:
+		return clause.Gte{Column: col, Value: value}, nil
+	case FilterLt:
+		return clause.Lt{Column: col, Value: value}, nil
+	case FilterLte:
+		return clause.Lte{Column: col, Value: value}, nil
+	case FilterLike:
+		s := fmt.Sprint(value)
+		if s == "" {
+			return nil, invalid
+		}
+		return clause.Like{Column: col, Value: "%" + s + "%"}, nil
+	case FilterIn:
+		values := filterValues(value)
+		if len(values) == 0 {
+			return nil, invalid
+		}
+		return clause.IN{Column: col, Values: values}, nil
+	case FilterBetween:
+		values := filterValues(value)
+		if len(values) != 2 {
+			return nil, invalid
+		}
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []interface{}{col, values[0], values[1]}}, nil
+	case FilterIsNull:
+		isNull, ok := value.(bool)
+		if !ok {
+			b, err := strconv.ParseBool(fmt.Sprint(value))
+			if err != nil {
+				return nil, invalid
+			}
+			isNull = b
+		}
+		if isNull {
+			return clause.Expr{SQL: "? IS NULL", Vars: []interface{}{col}}, nil
+		}
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []interface{}{col}}, nil
+	}
+	return nil, NewErrorModel(ERROR, "不支持的筛选操作: "+string(op), nil, http.StatusBadRequest)
+}
+
+// tableColumn 生成带主表名的列 避免JOIN关联后列名冲突 已包含表名(如 "Company.name")时保持不变
+func tableColumn(column string) clause.Column {
+	if strings.Contains(column, ".") {
+		return clause.Column{Name: column}
+	}
+	return clause.Column{Table: clause.CurrentTable, Name: column}
+}
+
+// filterValues 将切片或逗号分隔的字符串转换为参数列表
+func filterValues(value interface{}) []interface{} {
+	if s, ok := value.(string); ok {
+		if s == "" {
+			return nil
+		}
+		parts := strings.Split(s, ",")
+		values := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			values = append(values, strings.TrimSpace(part))
+		}
+		return values
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []interface{}{value}
+	}
+	values := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		values = append(values, v.Index(i).Interface())
+	}
+	return values
+}
@@ -129,8 +129,19 @@ func (g *GinActionImpl) DeleteOkWithMessage(message string) {
 	g.returnJsonWithStatusOK()
 }
 
+// SuccessWithETag 成功并通过ETag返回版本号 客户端更新时通过 If-Match 传回
+func (g *GinActionImpl) SuccessWithETag(data any, version int64) {
+	g.c.Header("ETag", FormatETag(version))
+	g.Success(data)
+}
+
 /** =================================request================================= */
 
+// IfMatch 获取客户端 If-Match 中的版本号
+func (g *GinActionImpl) IfMatch() (int64, bool) {
+	return ParseETag(g.c.GetHeader("If-Match"))
+}
+
 // BindParam 智能绑定参数（自动检测并处理 URI、Body、Query、Form 等参数）
 // 会自动识别结构体中的标签类型，选择正确的绑定顺序，最后统一验证
 func (g *GinActionImpl) BindParam(param interface{}) error {
@@ -284,8 +295,11 @@ func (g *GinActionImpl) bindNormalParams(param interface{}) error {
 }
 
 func NewGinActionImpl(c *gin.Context) *GinActionImpl {
+	req := NewRequest()
+	// 校验错误信息使用请求的语言
+	req.SetLocale(RequestLocale(c))
 	return &GinActionImpl{
 		c:   c,
-		req: NewRequest(),
+		req: req,
 	}
 }
@@ -0,0 +1,155 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormRepository BaseRepository 的通用GORM实现 主键通过GORM schema自动识别
+// 可识别的数据库错误会翻译为 ErrorModel 见 TranslateDBError
+// DAO 嵌入后只需编写自定义方法
+//
+//	type Organization struct {
+//		*helper.GormRepository[models.Organization]
+//	}
+//
+//	func NewOrganization() *Organization {
+//		return &Organization{
+//			GormRepository: helper.NewGormRepository[models.Organization](global.Config.GetDBConfig().GetGormDB()),
+//		}
+//	}
+type GormRepository[T any] struct {
+	*Util[T]
+}
+
+func NewGormRepository[T any](db *gorm.DB) *GormRepository[T] {
+	return &GormRepository[T]{
+		Util: NewUtil[T](db),
+	}
+}
+
+// WithContext 返回绑定了上下文的副本
+func (r *GormRepository[T]) WithContext(ctx context.Context) *GormRepository[T] {
+	return &GormRepository[T]{
+		Util: r.Util.WithContext(ctx),
+	}
+}
+
+// primaryKey 获取主键条件
+func (r *GormRepository[T]) primaryKey(id uint) (clause.Expression, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("模型%s没有主键", sch.Name)
+	}
+	return clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: sch.PrioritizedPrimaryField.DBName},
+		Value:  id,
+	}, nil
+}
+
+// GetOneById 根据主键获取 设置了缓存时使用缓存
+func (r *GormRepository[T]) GetOneById(id uint) (T, error) {
+	var entity T
+	model, err := r.GetById(id)
+	if err != nil {
+		return entity, wrapDBError(err)
+	}
+	return *model, nil
+}
+
+// GetOneByIdWith 根据主键获取 并加载指定的关联
+func (r *GormRepository[T]) GetOneByIdWith(id uint, relations ...Relation) (T, error) {
+	var entity T
+	pk, err := r.primaryKey(id)
+	if err != nil {
+		return entity, err
+	}
+	db, err := r.withRelations(r.reader().Model(r.Model).Where(pk), relations)
+	if err != nil {
+		return entity, err
+	}
+	err = db.First(&entity).Error
+	return entity, wrapDBError(err)
+}
+
+// FindByField 根据字段查询 字段必须是模型的字段 记录不存在时返回零值
+func (r *GormRepository[T]) FindByField(field string, value string) (T, error) {
+	var entity T
+	sch, err := r.schema()
+	if err != nil {
+		return entity, err
+	}
+	f := sch.LookUpField(field)
+	if f == nil || f.DBName == "" {
+		return entity, NewErrorModel(ERROR, "不支持的查询字段: "+field, nil, http.StatusBadRequest)
+	}
+	err = r.reader().Model(r.Model).
+		Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: value}).
+		First(&entity).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity, wrapDBError(err)
+	}
+	return entity, nil
+}
+
+// ExistsById 根据主键判断是否存在
+func (r *GormRepository[T]) ExistsById(id uint) (bool, error) {
+	pk, err := r.primaryKey(id)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	err = r.reader().Model(r.Model).Where(pk).Count(&count).Error
+	return count > 0, wrapDBError(err)
+}
+
+// DeleteById 根据主键删除
+func (r *GormRepository[T]) DeleteById(id uint) error {
+	pk, err := r.primaryKey(id)
+	if err != nil {
+		return err
+	}
+	// 审计需要带主键的模型
+	model := new(T)
+	sch, _ := r.schema()
+	if err = sch.PrioritizedPrimaryField.Set(context.Background(), reflect.ValueOf(model).Elem(), id); err != nil {
+		return err
+	}
+	err = r.audited(AuditDelete, model, func(u *Util[T]) error {
+		return affected(u.conn().Where(pk).Delete(new(T)))
+	})
+	if err == nil {
+		r.evictCacheIds(id)
+	}
+	return wrapDBError(err)
+}
+
+// Create 创建记录
+func (r *GormRepository[T]) Create(entity T) error {
+	return wrapDBError(r.CreateOne(&entity))
+}
+
+// Update 更新记录
+func (r *GormRepository[T]) Update(entity T) error {
+	return wrapDBError(r.UpdateOne(&entity))
+}
+
+// UpdateWithColumns 更新指定字段
+func (r *GormRepository[T]) UpdateWithColumns(entity T, columns ...string) error {
+	return wrapDBError(r.UpdateOneColumn(&entity, columns...))
+}
+
+// GetListData 分页查询
+func (r *GormRepository[T]) GetListData(request *PageRequest) (*PageList[T], error) {
+	list, err := r.GetList(request)
+	return list, wrapDBError(err)
+}
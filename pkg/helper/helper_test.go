@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB 打开测试使用的内存数据库 并创建模型的表
+func openTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	return openNamedTestDB(t, "", models...)
+}
+
+// openNamedTestDB 打开测试使用的命名内存数据库 同一测试中不同名称为不同的数据库
+func openNamedTestDB(t *testing.T, suffix string, models ...interface{}) *gorm.DB {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name()) + suffix
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// isolatedItem 租户隔离及数据权限测试使用的模型 TenantID 与 CreatedBy 相同
+type isolatedItem struct {
+	ID        uint
+	TenantID  uint
+	CreatedBy uint
+	Name      string
+	Status    int
+}
+
+// seedIsolated 写入两个租户(用户)的数据 id 1 2 属于1 id 3 4 属于2
+// id 3 能被 orWhereRequest 的or条件匹配 id 4 能被where条件匹配
+func seedIsolated(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openTestDB(t, &isolatedItem{})
+	items := []isolatedItem{
+		{TenantID: 1, CreatedBy: 1, Name: "a", Status: 1},
+		{TenantID: 1, CreatedBy: 1, Name: "b", Status: 2},
+		{TenantID: 2, CreatedBy: 2, Name: "secret", Status: 2},
+		{TenantID: 2, CreatedBy: 2, Name: "other", Status: 1},
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// orWhereRequest 带有能匹配其他租户(用户)数据的or条件的请求
+func orWhereRequest() *PageRequest {
+	p := NewPageReq()
+	p.Where["status = ?"] = 1
+	p.OrWhere["name = ?"] = "secret"
+	return p
+}
+
+// assertOwned 断言所有记录都属于租户(用户)1
+func assertOwned(t *testing.T, items []isolatedItem) {
+	t.Helper()
+	for _, item := range items {
+		if item.TenantID != 1 || item.CreatedBy != 1 {
+			t.Fatalf("查询到其他租户(用户)的数据: %+v", item)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package helper
+
+import (
+	"context"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+)
+
+// ImportMaxRows 单次导入最多的数据行数
+var ImportMaxRows = 50000
+
+// ImportMode 导入方式
+type ImportMode int
+
+const (
+	ImportAtomic     ImportMode = iota // 任一行失败时全部不写入 所有数据在一个事务中创建
+	ImportBestEffort                   // 只创建校验通过的行 写入失败的行记录到报告中
+)
+
+// ImportError 导入失败的行
+type ImportError struct {
+	Row     int    `json:"row"`     // 文件中的行号 表头为第1行
+	Field   string `json:"field"`   // 列名 整行的错误为空
+	Message string `json:"message"` // 错误信息
+}
+
+// ImportReport 导入结果
+type ImportReport struct {
+	Total   int           `json:"total"`   // 数据行数
+	Success int           `json:"success"` // 创建成功的行数
+	Failed  int           `json:"failed"`  // 失败的行数
+	Errors  []ImportError `json:"errors"`  // 失败的原因 一行可能有多条
+}
+
+// SheetRow 解析后的一行数据
+type SheetRow[D any] struct {
+	Row  int // 文件中的行号
+	Data D
+}
+
+// SheetFormatOf 根据文件名判断导入文件的格式
+func SheetFormatOf(filename string) (SheetFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")) {
+	case string(SheetCSV):
+		return SheetCSV, nil
+	case string(SheetXLSX):
+		return SheetXLSX, nil
+	}
+	return "", NewErrorModel(ERROR, "只支持导入csv或xlsx文件", nil, http.StatusBadRequest)
+}
+
+// ParseSheet 将CSV或XLSX文件解析为DTO 第一行为表头 按 excel 标签匹配列 并逐行校验
+// 校验错误信息使用上下文中的语言 返回校验通过的行及包含失败行的报告
+func ParseSheet[D any](ctx context.Context, reader io.Reader, format SheetFormat) ([]SheetRow[D], *ImportReport, error) {
+	records, err := readSheet(reader, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &ImportReport{Errors: make([]ImportError, 0)}
+	rows := make([]SheetRow[D], 0)
+	if len(records) == 0 {
+		return rows, report, nil
+	}
+
+	// 根据表头确定每列对应的字段
+	columns := sheetColumns(reflect.TypeOf(new(D)))
+	byHeader := make(map[string]sheetColumn, len(columns))
+	labels := make(map[string]string, len(columns))
+	for _, column := range columns {
+		byHeader[column.header] = column
+		labels[reflect.TypeOf(new(D)).Elem().FieldByIndex(column.index).Name] = column.header
+	}
+	locale, _ := LocaleFromContext(ctx)
+	trans := NewValidate().Translator(locale)
+	headers := make([]*sheetColumn, len(records[0]))
+	for i, header := range records[0] {
+		header = strings.TrimSpace(strings.TrimPrefix(header, "\xEF\xBB\xBF"))
+		if column, ok := byHeader[header]; ok {
+			headers[i] = &column
+		}
+	}
+
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		report.Total++
+		if report.Total > ImportMaxRows {
+			return nil, nil, NewErrorModel(ERROR, fmt.Sprintf("单次最多导入%d行", ImportMaxRows), nil, http.StatusBadRequest)
+		}
+		row := SheetRow[D]{Row: i + 2}
+		rv := reflect.ValueOf(&row.Data).Elem()
+		rowErrors := make([]ImportError, 0)
+		for j, cell := range record {
+			if j >= len(headers) || headers[j] == nil {
+				continue
+			}
+			if err = setSheetCell(rv.FieldByIndex(headers[j].index), strings.TrimSpace(cell)); err != nil {
+				rowErrors = append(rowErrors, ImportError{Row: row.Row, Field: headers[j].header, Message: headers[j].header + "格式不正确"})
+			}
+		}
+		// 格式不正确的列不再重复报告校验错误
+		for _, validateErr := range validateSheetRow(row.Row, &row.Data, labels, trans) {
+			if !hasImportError(rowErrors, validateErr.Field) {
+				rowErrors = append(rowErrors, validateErr)
+			}
+		}
+		if len(rowErrors) > 0 {
+			report.Failed++
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, report, nil
+}
+
+// Import 导入CSV或XLSX文件 解析校验后通过 CreateMany 批量创建 convert 将DTO转换为模型
+// ImportAtomic 模式下任一行失败时不写入任何数据
+//
+//	format, err := helper.SheetFormatOf(header.Filename)
+//	report, err := helper.Import(dao.WithContext(ctx), file, format, helper.ImportBestEffort,
+//		func(dto *UserImportRequest) (models.User, error) {
+//			return models.User{Name: dto.Name, Phone: dto.Phone}, nil
+//		})
+func Import[T any, D any](u *Util[T], reader io.Reader, format SheetFormat, mode ImportMode, convert func(dto *D) (T, error)) (*ImportReport, error) {
+	rows, report, err := ParseSheet[D](u.ctx, reader, format)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]T, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		model, err := convert(&row.Data)
+		if err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, ImportError{Row: row.Row, Message: importMessage(err)})
+			continue
+		}
+		models = append(models, model)
+		lines = append(lines, row.Row)
+	}
+	if mode == ImportAtomic {
+		if report.Failed > 0 || len(models) == 0 {
+			return report, nil
+		}
+		err = WithTx(u.ctx, u.DB, func(ctx context.Context) error {
+			tu := u.WithContext(ctx)
+			for start := 0; start < len(models); start += DefaultBatchSize {
+				end := min(start+DefaultBatchSize, len(models))
+				if err := tu.CreateMany(models[start:end]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, wrapDBError(err)
+		}
+		report.Success = len(models)
+		return report, nil
+	}
+
+	// 逐批创建 批次失败时逐行创建以找出失败的行
+	for start := 0; start < len(models); start += DefaultBatchSize {
+		end := min(start+DefaultBatchSize, len(models))
+		if err = u.CreateMany(models[start:end]); err == nil {
+			report.Success += end - start
+			continue
+		}
+		for i := start; i < end; i++ {
+			if err = u.CreateMany(models[i : i+1]); err != nil {
+				report.Failed++
+				report.Errors = append(report.Errors, ImportError{Row: lines[i], Message: importMessage(err)})
+				continue
+			}
+			report.Success++
+		}
+	}
+	return report, nil
+}
+
+// readSheet 读取文件的所有行 XLSX 读取第一个工作表
+func readSheet(reader io.Reader, format SheetFormat) ([][]string, error) {
+	invalid := NewErrorModel(ERROR, "文件解析失败", nil, http.StatusBadRequest)
+	switch format {
+	case SheetCSV:
+		r := csv.NewReader(reader)
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, invalid.Wrap(err)
+		}
+		return records, nil
+	case SheetXLSX:
+		file, err := excelize.OpenReader(reader)
+		if err != nil {
+			return nil, invalid.Wrap(err)
+		}
+		defer file.Close()
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil
+		}
+		records, err := file.GetRows(sheets[0])
+		if err != nil {
+			return nil, invalid.Wrap(err)
+		}
+		return records, nil
+	}
+	return nil, NewErrorModel(ERROR, "不支持的文件格式: "+string(format), nil, http.StatusBadRequest)
+}
+
+// isBlankRecord 是否为空行
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// validateSheetRow 使用请求参数的校验器校验一行 labels 为字段名对应的表头
+func validateSheetRow(row int, dto interface{}, labels map[string]string, trans ut.Translator) []ImportError {
+	err := binding.Validator.ValidateStruct(dto)
+	if err == nil {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []ImportError{{Row: row, Message: err.Error()}}
+	}
+	rowErrors := make([]ImportError, 0, len(errs))
+	for _, fe := range errs {
+		label, ok := labels[fe.StructField()]
+		if !ok {
+			label = fe.Field()
+		}
+		rowErrors = append(rowErrors, ImportError{Row: row, Field: label, Message: validateMessage(fe, label, trans)})
+	}
+	return rowErrors
+}
+
+// hasImportError 列是否已有错误
+func hasImportError(rowErrors []ImportError, field string) bool {
+	for _, e := range rowErrors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// importMessage 导入失败的原因 数据库错误翻译为可读的信息
+func importMessage(err error) string {
+	var errModel *ErrorModel
+	if errors.As(err, &errModel) {
+		return errModel.Message
+	}
+	if errModel = TranslateDBError(err); errModel != nil {
+		return errModel.Message
+	}
+	return err.Error()
+}
+
+// sheetTimeLayouts 导入时支持的时间格式
+var sheetTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02", "2006/01/02 15:04:05", "2006/01/02", time.RFC3339}
+
+// setSheetCell 将单元格的文本写入字段 空文本保持零值
+func setSheetCell(field reflect.Value, text string) error {
+	if text == "" {
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(field.Type().Elem())
+		if err := setSheetCell(value.Elem(), text); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(text))
+	}
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		for _, layout := range append([]string{SheetTimeLayout}, sheetTimeLayouts...) {
+			if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("无法解析时间: %s", text)
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	case reflect.Bool:
+		switch text {
+		case "是", "Y", "y":
+			field.SetBool(true)
+		case "否", "N", "n":
+			field.SetBool(false)
+		default:
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return err
+			}
+			field.SetBool(b)
+		}
+	default:
+		return fmt.Errorf("不支持的字段类型: %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type importForm struct {
+	Name   string `excel:"名称" binding:"required"`
+	Remark string `excel:"备注"`
+}
+
+func TestParseSheetLocale(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"default", context.Background(), "名称为必填字段"},
+		{"en", WithLocale(context.Background(), LocaleEn), "名称 is a required field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, report, err := ParseSheet[importForm](tt.ctx, strings.NewReader("名称,备注\n,a\nx,b\n"), SheetCSV)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Failed != 1 || len(report.Errors) != 1 {
+				t.Fatalf("期望1行失败 实际 %+v", report)
+			}
+			if got := report.Errors[0].Message; got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的校验错误信息语言
+const (
+	LocaleZh = "zh"
+	LocaleEn = "en"
+)
+
+// DefaultLocale 未指定语言或语言不支持时使用的语言
+var DefaultLocale = LocaleZh
+
+// localeContextKey 语言在上下文中的key
+type localeContextKey struct{}
+
+// WithLocale 将语言保存到上下文 优先于请求头的 Accept-Language
+func WithLocale(ctx context.Context, locale string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFromContext 获取上下文中的语言
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	locale, ok := ctx.Value(localeContextKey{}).(string)
+	return locale, ok && locale != ""
+}
+
+// RequestLocale 获取请求的语言 优先使用请求上下文中的语言 其次为 Accept-Language
+func RequestLocale(c *gin.Context) string {
+	if c.Request != nil {
+		if locale, ok := LocaleFromContext(c.Request.Context()); ok {
+			return locale
+		}
+	}
+	return ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+}
+
+// ParseAcceptLanguage 解析 Accept-Language 按权重返回第一个支持的语言 例如 "en-US,en;q=0.9" 返回 en
+func ParseAcceptLanguage(header string) string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	languages := make([]language, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, field := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(field), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		languages = append(languages, language{tag: tag, q: q})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].q > languages[j].q
+	})
+	v := NewValidate()
+	for _, lang := range languages {
+		// zh-CN 等地区语言使用基础语言
+		base := strings.SplitN(strings.ReplaceAll(lang.tag, "_", "-"), "-", 2)[0]
+		if _, found := v.uni.GetTranslator(base); found && lang.q > 0 {
+			return base
+		}
+	}
+	return DefaultLocale
+}
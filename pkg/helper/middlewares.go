@@ -1,9 +1,11 @@
 package helper
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"runtime"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -52,3 +54,13 @@ func GinException() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// GinTimeout 请求超时中间件 超时后请求上下文会被取消 使用该上下文的数据库查询随之中断
+func GinTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,147 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrOptimisticLock 乐观锁冲突 记录已被其他请求修改
+var ErrOptimisticLock = NewErrorModel(ERROR, "数据已被修改，请刷新后重试", nil, http.StatusConflict)
+
+// ifMatchContextKey If-Match 版本号在上下文中的key
+type ifMatchContextKey struct{}
+
+// WithIfMatch 将客户端期望的版本号保存到上下文 更新时不会自动使用 需通过 UpdateOneIfMatch 显式传入
+func WithIfMatch(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, ifMatchContextKey{}, version)
+}
+
+// IfMatchFromContext 获取上下文中客户端期望的版本号
+func IfMatchFromContext(ctx context.Context) (int64, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	version, ok := ctx.Value(ifMatchContextKey{}).(int64)
+	return version, ok
+}
+
+// FormatETag 将版本号格式化为ETag
+func FormatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ParseETag 解析ETag中的版本号 支持 "3" W/"3" 3 三种格式
+func ParseETag(tag string) (int64, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	tag = strings.Trim(tag, `"`)
+	if tag == "" {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	return version, err == nil
+}
+
+// versionField 获取乐观锁版本字段 通过标签 lock:"version" 声明 字段必须为整数类型
+//
+//	type Article struct {
+//		ID      uint
+//		Title   string
+//		Version int64 `lock:"version"`
+//	}
+func (u *Util[T]) versionField() (*schema.Field, error) {
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range sch.Fields {
+		if field.Tag.Get("lock") != "version" || field.DBName == "" {
+			continue
+		}
+		switch field.FieldType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return field, nil
+		}
+		return nil, fmt.Errorf("模型%s的版本字段%s必须为整数类型", sch.Name, field.Name)
+	}
+	return nil, nil
+}
+
+// GetVersion 获取记录的版本号 模型没有版本字段时返回false 可用于设置ETag
+func (u *Util[T]) GetVersion(model *T) (int64, bool) {
+	field, err := u.versionField()
+	if err != nil || field == nil {
+		return 0, false
+	}
+	value, _ := field.ValueOf(context.Background(), reflect.ValueOf(model).Elem())
+	return versionInt64(value), true
+}
+
+// UpdateOneIfMatch 以客户端传入的版本号(If-Match)作为期望的版本号更新一条记录
+// 版本号不一致时返回 ErrOptimisticLock 模型必须有版本字段
+//
+//	version, ok := helper.IfMatchFromContext(req.Ctx)
+//	if ok {
+//		err = dao.UpdateOneIfMatch(article, version)
+//	}
+func (u *Util[T]) UpdateOneIfMatch(model *T, version int64) error {
+	field, err := u.versionField()
+	if err != nil {
+		return err
+	}
+	if field == nil {
+		return errors.New("模型没有版本字段 请使用 lock:\"version\" 标签声明")
+	}
+	if err = field.Set(context.Background(), reflect.ValueOf(model).Elem(), version); err != nil {
+		return err
+	}
+	return u.UpdateOne(model)
+}
+
+// updateWithVersion 以模型当前的版本号为条件更新 成功后版本号加一 未更新到记录时返回 ErrOptimisticLock
+func (u *Util[T]) updateWithVersion(model *T, field *schema.Field, columns []string) error {
+	db := u.conn()
+	ctx := db.Statement.Context
+	rv := reflect.ValueOf(model).Elem()
+	current, _ := field.ValueOf(ctx, rv)
+	expected := versionInt64(current)
+	if err := field.Set(ctx, rv, expected+1); err != nil {
+		return err
+	}
+
+	db = db.Model(model).Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+		Value:  expected,
+	})
+	if columns != nil {
+		db = db.Select(append(append([]string{}, columns...), field.DBName))
+	}
+	db = db.Updates(model)
+	if db.Error == nil && db.RowsAffected == 0 {
+		db.Error = ErrOptimisticLock
+	}
+	if db.Error != nil {
+		_ = field.Set(ctx, rv, current)
+	}
+	return db.Error
+}
+
+// versionInt64 将整数类型的版本号转换为int64
+func versionInt64(value interface{}) int64 {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return 0
+}
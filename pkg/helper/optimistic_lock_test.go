@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type lockArticle struct {
+	ID      uint
+	Title   string
+	Version int64 `lock:"version"`
+}
+
+type lockTag struct {
+	ID      uint
+	Name    string
+	Version int64 `lock:"version"`
+}
+
+func TestIfMatchNotAppliedToOtherModels(t *testing.T) {
+	db := openTestDB(t, &lockArticle{}, &lockTag{})
+	db.Create(&lockArticle{Title: "a", Version: 1})
+	db.Create(&lockTag{Name: "t", Version: 1})
+	// 上下文中的 If-Match 不影响同一请求中其他记录的更新
+	ctx := WithIfMatch(context.Background(), 0)
+	tags := NewUtil[lockTag](db).WithContext(ctx)
+	tag := &lockTag{ID: 1, Name: "t2", Version: 1}
+	if err := tags.UpdateOne(tag); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	if tag.Version != 2 {
+		t.Fatalf("期望版本号为2 实际%d", tag.Version)
+	}
+}
+
+func TestUpdateOneIfMatch(t *testing.T) {
+	db := openTestDB(t, &lockArticle{})
+	db.Create(&lockArticle{Title: "a", Version: 3})
+	articles := NewUtil[lockArticle](db)
+	if err := articles.UpdateOneIfMatch(&lockArticle{ID: 1, Title: "b"}, 2); !errors.Is(err, ErrOptimisticLock) {
+		t.Fatalf("期望 ErrOptimisticLock 实际 %v", err)
+	}
+	article := &lockArticle{ID: 1, Title: "b"}
+	if err := articles.UpdateOneIfMatch(article, 3); err != nil {
+		t.Fatal(err)
+	}
+	if article.Version != 4 {
+		t.Fatalf("期望版本号为4 实际%d", article.Version)
+	}
+}
@@ -1,15 +1,26 @@
 package helper
 
 import (
+	"context"
 	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 type Util[T interface{}] struct {
 	DB                *gorm.DB
 	Model             *T
 	PageRequestParams *PageRequest
+
+	ctx     context.Context // 请求上下文 用于获取事务及取消查询
+	cluster *DBCluster      // 主从数据库连接 为空时读写都使用DB
+	audit   AuditSink       // 变更记录的存储 为空时不记录
+	cache   *cacheConfig    // 主键查询的缓存 为空时不缓存
+	tree    *TreeOptions    // 树形模型的列配置 为空时使用 parent_id
+
+	dataScope *DataScopeRule // 列表查询的数据权限规则
 }
 
 func NewUtil[T interface{}](db *gorm.DB) *Util[T] {
@@ -19,30 +30,79 @@ func NewUtil[T interface{}](db *gorm.DB) *Util[T] {
 	}
 }
 
-// GetOne 获取一条记录
-func (u *Util[T]) GetOne(model *T) error {
-	return u.DB.Model(u.Model).First(model).Error
+// WithContext 返回绑定了上下文的副本 查询会随上下文取消或超时而中断
+// 上下文中有同一数据库的事务时所有操作都在事务中执行
+//
+//	list, err := dao.WithContext(req.Ctx).GetList(page)
+func (u *Util[T]) WithContext(ctx context.Context) *Util[T] {
+	clone := *u
+	clone.ctx = ctx
+	return &clone
+}
+
+// conn 获取写操作使用的连接(主库)
+func (u *Util[T]) conn() *gorm.DB {
+	return u.session(u.DB)
+}
+
+// reader 获取读操作使用的连接 有从库时使用从库 上下文强制读主库时使用主库
+func (u *Util[T]) reader() *gorm.DB {
+	if u.cluster != nil && !IsForcePrimary(u.ctx) {
+		return u.session(u.cluster.Reader())
+	}
+	return u.session(u.DB)
 }
 
-// GetList 获取多条记录
+// session 绑定上下文及租户条件 优先使用上下文中主库所属数据库的事务
+func (u *Util[T]) session(db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(u.ctx, u.DB); ok {
+		db = tx
+	}
+	if u.ctx != nil {
+		db = db.WithContext(u.ctx)
+	}
+	return db.Scopes(u.tenantScope).Session(&gorm.Session{})
+}
+
+// listDB 列表查询使用的连接 加载关联并添加数据权限条件
+func (u *Util[T]) listDB(relations []Relation) (*gorm.DB, error) {
+	db := u.reader().Model(u.Model)
+	if u.dataScope != nil {
+		db = db.Scopes(u.dataScope.Scope).Set(dataScopeSettingKey, true)
+	}
+	return u.withRelations(db, relations)
+}
+
+// GetOne 获取一条记录 可传入需要加载的关联
+func (u *Util[T]) GetOne(model *T, relations ...Relation) error {
+	db, err := u.withRelations(u.reader().Model(u.Model), relations)
+	if err != nil {
+		return err
+	}
+	return db.First(model).Error
+}
+
+// GetList 获取多条记录 加载 PageRequest.With 设置的关联 总数按 PageRequest.CountMode 统计
 func (u *Util[T]) GetList(request *PageRequest) (*PageList[T], error) {
+	db, err := u.listDB(request.relations)
+	if err != nil {
+		return nil, err
+	}
 	list := NewPageList[T]()
 	list.Data = make([]T, 0)
-	err := u.DB.Model(u.Model).Scopes(Paginate(request)).Find(&list.Data).Offset(-1).Count(&list.Total).Error
-	list.Page = request.Page
-	list.PageSize = request.PageSize
-	if err != nil {
+	if err = findPage(db, request, list); err != nil {
 		return nil, err
-
 	}
 	return list, nil
 }
 
 // GetListWithData  获取多条记录 使用传入的data进行返回赋值 ， 第二个参数需要传入指针
+//
+// Deprecated: 返回的 PageList.Data 为空 请使用类型安全的 GetListAs
 func (u *Util[T]) GetListWithData(request *PageRequest, data interface{}) (*PageList[T], error) {
 	list := NewPageList[T]()
 	list.Data = make([]T, 0)
-	err := u.DB.Model(u.Model).Scopes(Paginate(request)).Find(data).Offset(-1).Count(&list.Total).Error
+	err := u.reader().Model(u.Model).Scopes(Paginate(request)).Find(data).Offset(-1).Count(&list.Total).Error
 	list.Page = request.Page
 	list.PageSize = request.PageSize
 	if err != nil {
@@ -55,7 +115,7 @@ func (u *Util[T]) GetListWithData(request *PageRequest, data interface{}) (*Page
 // GetAll 获取所有记录
 func (u *Util[T]) GetAll() ([]T, error) {
 	all := make([]T, 0)
-	err := u.DB.Model(u.Model).Find(&all).Error
+	err := u.reader().Model(u.Model).Find(&all).Error
 	if err != nil {
 		return nil, err
 	}
@@ -64,48 +124,120 @@ func (u *Util[T]) GetAll() ([]T, error) {
 
 // CreateOne 创建一条记录
 func (u *Util[T]) CreateOne(model *T) error {
-	return u.DB.Model(u.Model).Create(model).Error
+	if err := u.stampTenant(model); err != nil {
+		return err
+	}
+	if _, err := u.stampActor(model, AuditCreate); err != nil {
+		return err
+	}
+	return u.audited(AuditCreate, model, func(u *Util[T]) error {
+		return u.conn().Model(u.Model).Create(model).Error
+	})
 }
 
 // CreateMany 创建多条记录
 func (u *Util[T]) CreateMany(model []T) error {
-	return u.DB.Model(u.Model).Create(model).Error
+	if err := u.stampTenant(model); err != nil {
+		return err
+	}
+	return u.conn().Model(u.Model).Create(model).Error
 }
 
-// UpdateOne 更新一条记录
+// UpdateOne 更新一条记录 模型有版本字段时使用乐观锁
 func (u *Util[T]) UpdateOne(model *T) error {
-	return u.DB.Model(model).Updates(model).Error
+	if err := u.stampTenant(model); err != nil {
+		return err
+	}
+	if _, err := u.stampActor(model, AuditUpdate); err != nil {
+		return err
+	}
+	field, err := u.versionField()
+	if err != nil {
+		return err
+	}
+	return u.evictAfter(model, u.audited(AuditUpdate, model, func(u *Util[T]) error {
+		if field != nil {
+			return u.updateWithVersion(model, field, nil)
+		}
+		return affected(u.conn().Model(model).Updates(model))
+	}))
 }
 
-// UpdateOneColumn 根据字段名更新单列
+// UpdateOneColumn 根据字段名更新单列 模型有版本字段时使用乐观锁
 func (u *Util[T]) UpdateOneColumn(model *T, column ...string) error {
-	return u.DB.Model(model).Select(column).Updates(model).Error
-
+	if err := u.stampTenant(model); err != nil {
+		return err
+	}
+	stamped, err := u.stampActor(model, AuditUpdate)
+	if err != nil {
+		return err
+	}
+	if len(column) > 0 {
+		// 复制后追加 不修改调用方的切片
+		column = append(append([]string{}, column...), stamped...)
+	}
+	field, err := u.versionField()
+	if err != nil {
+		return err
+	}
+	return u.evictAfter(model, u.audited(AuditUpdate, model, func(u *Util[T]) error {
+		if field != nil {
+			return u.updateWithVersion(model, field, column)
+		}
+		return affected(u.conn().Model(model).Select(column).Updates(model))
+	}))
 }
 
 // UpdateMany 更新多条记录
 func (u *Util[T]) UpdateMany(model []T) error {
-	return u.DB.Model(model).Updates(model).Error
+	if err := u.stampTenant(model); err != nil {
+		return err
+	}
+	return u.evictAfter(model, u.conn().Model(model).Updates(model).Error)
 }
 
 // DeleteOne 删除一条记录
 func (u *Util[T]) DeleteOne(model *T) error {
-	return u.DB.Model(u.Model).Delete(model).Error
+	return u.evictAfter(model, u.audited(AuditDelete, model, func(u *Util[T]) error {
+		return affected(u.conn().Model(u.Model).Delete(model))
+	}))
 }
 
 // DeleteMany 删除多条记录
 func (u *Util[T]) DeleteMany(model []T) error {
-	return u.DB.Model(u.Model).Delete(model).Error
+	return u.evictAfter(model, u.conn().Model(u.Model).Delete(model).Error)
 }
 
-// SetDB 修改DB
+// SetDB 修改DB 有从库时同时修改从库 保证读写使用相同的配置
 func (u *Util[T]) SetDB(fn func(db *gorm.DB) *gorm.DB) {
 	u.DB = fn(u.DB)
+	if u.cluster != nil {
+		// 注册的连接可能被其他 Util 共用 不修改原连接
+		replicas := make([]*gorm.DB, 0, len(u.cluster.Replicas))
+		for _, replica := range u.cluster.Replicas {
+			replicas = append(replicas, fn(replica))
+		}
+		u.cluster = NewDBCluster(u.DB, replicas...)
+	}
 }
 
-// GetDB 获取DB
+// GetDB 获取DB(主库) 上下文中有同一数据库的事务时返回事务
 func (u *Util[T]) GetDB() *gorm.DB {
-	return u.DB
+	return u.conn()
+}
+
+// GetReadDB 获取读库 有从库时返回从库 上下文中有同一数据库的事务或强制读主库时返回主库
+func (u *Util[T]) GetReadDB() *gorm.DB {
+	return u.reader()
+}
+
+// schema 解析模型T的GORM schema
+func (u *Util[T]) schema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: u.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
 }
 
 // PageRequest 分页请求的参数
@@ -115,8 +247,19 @@ type PageRequest struct {
 	Total    int64                  `json:"total"`
 	Where    map[string]interface{} // 条件and 自行拼接
 	OrWhere  map[string]interface{} // 条件or 自行拼接
+	Cursor   string                 `json:"cursor"` // 游标分页的游标 为空时从第一页开始
 	asc      string                 // 正序排序
 	desc     string                 //倒序排序
+
+	WithTrashed bool      `json:"-" form:"-"` // 是否包含已软删除的记录 由服务端设置 不从请求参数绑定
+	CountMode   CountMode `json:"-" form:"-"` // 总数统计方式 默认精确统计
+	CountCap    int64     `json:"-" form:"-"` // CountCapped 模式下最多统计的条数
+
+	cursorKeys []string               // 游标分页的排序键
+	filters    []clause.Expression    // 白名单校验后的筛选条件
+	orders     []clause.OrderByColumn // 校验后的排序字段
+	relations  []Relation             // 需要加载的关联
+	dataScope  *DataScopeRule         // 数据权限规则
 }
 
 // NewPageReq 初始化分页请求参数 默认第一页 每页10条
@@ -151,27 +294,8 @@ func Paginate(p *PageRequest) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		db.Session(&gorm.Session{})
 
-		if p.Page == 0 {
-			p.Page = 1
-		}
-		switch {
-		case p.PageSize > 100:
-			p.PageSize = 100
-		case p.PageSize <= 0:
-			p.PageSize = 10
-		}
-		// 拼接where条件
-		if p.Where != nil {
-			for k, v := range p.Where {
-				db.Where(k, v)
-			}
-		}
-		// 拼接or条件
-		if p.OrWhere != nil {
-			for k, v := range p.OrWhere {
-				db.Or(k, v)
-			}
-		}
+		p.normalize()
+		db = p.buildWhere(db)
 		// 拼接正序排序
 
 		if p.asc != "" {
@@ -198,12 +322,69 @@ func Paginate(p *PageRequest) func(db *gorm.DB) *gorm.DB {
 				db.Order(p.desc + " desc")
 			}
 		}
+		// 拼接校验后的排序字段
+		for _, order := range p.orders {
+			db = db.Order(order)
+		}
 		offset := (p.Page - 1) * p.PageSize
 		// 分页查询
 		return db.Offset(offset).Limit(p.PageSize)
 	}
 }
 
+// normalize 规范分页参数 默认第一页 每页最多100条
+func (p *PageRequest) normalize() {
+	if p.Page == 0 {
+		p.Page = 1
+	}
+	switch {
+	case p.PageSize > 100:
+		p.PageSize = 100
+	case p.PageSize <= 0:
+		p.PageSize = 10
+	}
+}
+
+// buildWhere 拼接where和or条件
+// 请求的条件整体加括号后再与租户 数据权限等条件AND 防止OrWhere绕过隔离条件
+func (p *PageRequest) buildWhere(db *gorm.DB) *gorm.DB {
+	if p.WithTrashed {
+		db = db.Unscoped()
+	}
+	// 拼接数据权限条件
+	if p.dataScope != nil {
+		db = p.dataScope.Scope(db)
+	}
+	if cond := p.conditions(db); cond != nil {
+		db = db.Where(cond)
+	}
+	return db
+}
+
+// conditions 将where 筛选条件和or条件组合为一个表达式 没有条件时返回nil
+// 组合后为 (where AND filters) OR or1 OR or2
+func (p *PageRequest) conditions(db *gorm.DB) clause.Expression {
+	ands := make([]clause.Expression, 0, len(p.Where)+len(p.filters))
+	for k, v := range p.Where {
+		ands = append(ands, db.Statement.BuildCondition(k, v)...)
+	}
+	ands = append(ands, p.filters...)
+	ors := make([]clause.Expression, 0, len(p.OrWhere)+1)
+	if len(ands) > 0 {
+		ors = append(ors, clause.And(ands...))
+	}
+	for k, v := range p.OrWhere {
+		ors = append(ors, clause.And(db.Statement.BuildCondition(k, v)...))
+	}
+	switch len(ors) {
+	case 0:
+		return nil
+	case 1:
+		return clause.And(ors[0])
+	}
+	return clause.Or(ors...)
+}
+
 // AscSort 正序排序 多个排序字段使用空格隔开
 func (p *PageRequest) AscSort(field string) {
 	p.asc = field
@@ -213,3 +394,9 @@ func (p *PageRequest) AscSort(field string) {
 func (p *PageRequest) DescSort(field string) {
 	p.desc = field
 }
+
+// CursorSort 游标分页的排序键 按顺序比较 字段前加"-"表示倒序 例如 CursorSort("-created_at", "-id")
+// 未包含主键时会自动追加主键 保证排序稳定
+func (p *PageRequest) CursorSort(keys ...string) {
+	p.cursorKeys = keys
+}
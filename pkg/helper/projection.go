@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"net/http"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetListAs 分页获取多条记录并映射到结果结构体R 只查询R中与模型T同名的列
+// mappers 会按顺序作用于每一行 可用于脱敏或补充字段
+// 关联只支持 Join 用于筛选和排序 预加载需要在模型T上执行 请使用 GetList
+//
+//	type UserItem struct {
+//		ID   uint   `json:"id"`
+//		Name string `json:"name"`
+//	}
+//
+//	list, err := helper.GetListAs[models.User, UserItem](dao.Util, page)
+func GetListAs[T any, R any](u *Util[T], request *PageRequest, mappers ...func(row *R)) (*PageList[R], error) {
+	for _, relation := range request.relations {
+		if !relation.join {
+			return nil, NewErrorModel(ERROR, "GetListAs 不支持预加载关联: "+relation.Name, nil, http.StatusBadRequest)
+		}
+	}
+	columns, err := projectColumns[T, R](u.DB)
+	if err != nil {
+		return nil, err
+	}
+	db, err := u.listDB(request.relations)
+	if err != nil {
+		return nil, err
+	}
+	list := NewPageList[R]()
+	list.Data = make([]R, 0)
+	if err = findPage(db.Clauses(clause.Select{Columns: columns}), request, list); err != nil {
+		return nil, err
+	}
+	for i := range list.Data {
+		for _, mapper := range mappers {
+			mapper(&list.Data[i])
+		}
+	}
+	return list, nil
+}
+
+// projectColumns 获取结果结构体R中存在于模型T的列 列名带主表名 避免与JOIN的关联冲突
+func projectColumns[T any, R any](db *gorm.DB) ([]clause.Column, error) {
+	model := &gorm.Statement{DB: db}
+	if err := model.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	result := &gorm.Statement{DB: db}
+	if err := result.Parse(new(R)); err != nil {
+		return nil, err
+	}
+	columns := make([]clause.Column, 0, len(result.Schema.DBNames))
+	for _, name := range result.Schema.DBNames {
+		if _, ok := model.Schema.FieldsByDBName[name]; ok {
+			columns = append(columns, clause.Column{Table: clause.CurrentTable, Name: name})
+		}
+	}
+	return columns, nil
+}
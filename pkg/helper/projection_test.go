@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+)
+
+type joinCompany struct {
+	ID   uint
+	Name string
+}
+
+type joinItem struct {
+	ID        uint
+	Name      string
+	CompanyID uint
+	Company   joinCompany
+}
+
+type joinItemRow struct {
+	ID   uint
+	Name string
+}
+
+// setupJoin 写入两家公司及三条记录 记录与公司的name列同名
+func setupJoin(t *testing.T) *Util[joinItem] {
+	t.Helper()
+	db := openTestDB(t, &joinCompany{}, &joinItem{})
+	db.Create(&[]joinCompany{{Name: "acme"}, {Name: "globex"}})
+	db.Create(&[]joinItem{{Name: "a", CompanyID: 1}, {Name: "b", CompanyID: 2}, {Name: "c", CompanyID: 1}})
+	return NewUtil[joinItem](db)
+}
+
+func TestGetListAsWithJoin(t *testing.T) {
+	u := setupJoin(t)
+	p := NewPageReq().With(Join("Company"))
+	p.OrderBy("id", true)
+	for _, mode := range []CountMode{CountExact, CountCapped, CountParallel} {
+		p.CountMode = mode
+		p.CountCap = 10
+		list, err := GetListAs[joinItem, joinItemRow](u, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.Total != 3 || len(list.Data) != 3 || list.Data[0].ID != 3 || list.Data[0].Name != "c" {
+			t.Fatalf("统计方式%d 结果不正确: %+v", mode, list)
+		}
+	}
+}
+
+func TestFilterWithJoin(t *testing.T) {
+	u := setupJoin(t)
+	p := NewPageReq().With(Join("Company"))
+	if err := p.AddFilter("name", FilterEq, "b"); err != nil {
+		t.Fatal(err)
+	}
+	p.OrderBy("name", false)
+	list, err := u.GetList(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 1 || list.Data[0].Name != "b" || list.Data[0].Company.Name != "globex" {
+		t.Fatalf("结果不正确: %+v", list)
+	}
+
+	rows, err := GetListAs[joinItem, joinItemRow](u, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Total != 1 || rows.Data[0].Name != "b" {
+		t.Fatalf("结果不正确: %+v", rows)
+	}
+}
+
+func TestGetListAsRejectsPreload(t *testing.T) {
+	u := setupJoin(t)
+	_, err := GetListAs[joinItem, joinItemRow](u, NewPageReq().With(Preload("Company")))
+	var errModel *ErrorModel
+	if !errors.As(err, &errModel) {
+		t.Fatalf("期望返回 ErrorModel 实际 %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Relation 关联加载选项
+type Relation struct {
+	Name  string        // 关联字段名 嵌套关联使用"."连接 例如 "Orders.Items"
+	Conds []interface{} // 关联的查询条件
+	join  bool          // 是否使用JOIN加载
+}
+
+// Preload 使用独立查询预加载关联 可传入关联的查询条件
+//
+//	helper.Preload("Orders", "state = ?", "paid")
+//	helper.Preload("Orders.Items")
+func Preload(name string, conds ...interface{}) Relation {
+	return Relation{Name: name, Conds: conds}
+}
+
+// Join 使用LEFT JOIN加载关联 仅支持 belongs to 和 has one 关联
+// 关联的查询条件需要传入 *gorm.DB 例如 helper.Join("Company", db.Where(&Company{Alive: true}))
+func Join(name string, conds ...interface{}) Relation {
+	return Relation{Name: name, Conds: conds, join: true}
+}
+
+// With 追加需要加载的关联 返回自身便于链式调用
+func (p *PageRequest) With(relations ...Relation) *PageRequest {
+	p.relations = append(p.relations, relations...)
+	return p
+}
+
+// withRelations 校验关联是否存在于模型的schema中并添加到查询
+func (u *Util[T]) withRelations(db *gorm.DB, relations []Relation) (*gorm.DB, error) {
+	if len(relations) == 0 {
+		return db, nil
+	}
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if err = validateRelation(sch, relation); err != nil {
+			return nil, err
+		}
+		if relation.join {
+			db = db.Joins(relation.Name, relation.Conds...)
+		} else {
+			db = db.Preload(relation.Name, relation.Conds...)
+		}
+	}
+	return db, nil
+}
+
+// validateRelation 校验关联路径
+func validateRelation(sch *schema.Schema, relation Relation) error {
+	invalid := NewErrorModel(ERROR, "无效的关联: "+relation.Name, nil, http.StatusBadRequest)
+	if relation.Name == clause.Associations && !relation.join {
+		return nil
+	}
+	current := sch
+	for _, name := range strings.Split(relation.Name, ".") {
+		rel, ok := current.Relationships.Relations[name]
+		if !ok {
+			return invalid
+		}
+		if relation.join && rel.Type != schema.BelongsTo && rel.Type != schema.HasOne {
+			return NewErrorModel(ERROR, "关联"+relation.Name+"不支持JOIN加载", nil, http.StatusBadRequest)
+		}
+		current = rel.FieldSchema
+	}
+	return nil
+}
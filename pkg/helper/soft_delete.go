@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// deletedAtField 获取模型的软删除字段(gorm.DeletedAt)
+func (u *Util[T]) deletedAtField() (*schema.Field, error) {
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	deletedAtType := reflect.TypeOf(gorm.DeletedAt{})
+	for _, field := range sch.Fields {
+		if field.FieldType == deletedAtType && field.DBName != "" {
+			return field, nil
+		}
+	}
+	return nil, fmt.Errorf("模型%s没有gorm.DeletedAt字段 不支持软删除", sch.Name)
+}
+
+// SoftDelete 软删除一条记录 模型必须包含 gorm.DeletedAt 字段
+func (u *Util[T]) SoftDelete(model *T) error {
+	if _, err := u.deletedAtField(); err != nil {
+		return err
+	}
+	return u.evictAfter(model, u.audited(AuditDelete, model, func(u *Util[T]) error {
+		return affected(u.conn().Model(u.Model).Delete(model))
+	}))
+}
+
+// Restore 恢复一条已软删除的记录
+func (u *Util[T]) Restore(model *T) error {
+	field, err := u.deletedAtField()
+	if err != nil {
+		return err
+	}
+	return u.evictAfter(model, u.audited(AuditUpdate, model, func(u *Util[T]) error {
+		return affected(u.conn().Unscoped().Model(model).Update(field.DBName, nil))
+	}))
+}
+
+// ForceDelete 物理删除一条记录 包括已软删除的记录
+func (u *Util[T]) ForceDelete(model *T) error {
+	return u.evictAfter(model, u.audited(AuditDelete, model, func(u *Util[T]) error {
+		return affected(u.conn().Unscoped().Model(u.Model).Delete(model))
+	}))
+}
+
+// ListTrashed 分页获取已软删除的记录(回收站)
+func (u *Util[T]) ListTrashed(request *PageRequest) (*PageList[T], error) {
+	field, err := u.deletedAtField()
+	if err != nil {
+		return nil, err
+	}
+	trashed := *request
+	trashed.WithTrashed = true
+	trashed.filters = append(append([]clause.Expression{}, request.filters...), clause.Expr{
+		SQL:  "? IS NOT NULL",
+		Vars: []interface{}{clause.Column{Table: clause.CurrentTable, Name: field.DBName}},
+	})
+	list, err := u.GetList(&trashed)
+	request.Page, request.PageSize = trashed.Page, trashed.PageSize
+	return list, err
+}
@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// SortWhitelist 允许排序的字段白名单 key为对外暴露的字段名 value为数据库列名
+type SortWhitelist map[string]string
+
+// NewSortWhitelist 创建字段名与列名相同的排序白名单
+func NewSortWhitelist(columns ...string) SortWhitelist {
+	sorts := make(SortWhitelist, len(columns))
+	for _, column := range columns {
+		sorts[column] = column
+	}
+	return sorts
+}
+
+// ToPageRequest 将列表请求转换为分页请求 并校验排序字段
+// Field 支持多个字段 使用逗号隔开 字段前加"-"表示倒序 加"+"表示正序 例如 field=created_at,-id
+// 未指定方向的字段使用 Order(asc/desc) 默认正序
+func (l *ListRequest) ToPageRequest(sorts SortWhitelist) (*PageRequest, error) {
+	p := NewPageReq()
+	p.Page = l.Page
+	p.PageSize = l.PageSize
+
+	defaultDesc := false
+	switch strings.ToLower(strings.TrimSpace(l.Order)) {
+	case "", "asc":
+	case "desc":
+		defaultDesc = true
+	default:
+		return nil, NewErrorModel(ERROR, "排序方式只能为asc或desc", nil, http.StatusBadRequest)
+	}
+
+	for _, field := range strings.Split(l.Field, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		desc := defaultDesc
+		switch field[0] {
+		case '-':
+			desc = true
+			field = field[1:]
+		case '+':
+			desc = false
+			field = field[1:]
+		}
+		column, ok := sorts[field]
+		if !ok {
+			return nil, NewErrorModel(ERROR, "不支持的排序字段: "+field, nil, http.StatusBadRequest)
+		}
+		p.OrderBy(column, desc)
+	}
+	return p, nil
+}
+
+// OrderBy 追加排序字段 列名会被转义 可多次调用实现多列排序
+func (p *PageRequest) OrderBy(column string, desc bool) {
+	p.orders = append(p.orders, clause.OrderByColumn{
+		Column: tableColumn(column),
+		Desc:   desc,
+	})
+}
@@ -0,0 +1,128 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrTenantRequired 开启租户隔离后 上下文中缺少租户信息
+var ErrTenantRequired = NewErrorModel(ERROR, "缺少租户信息", nil, http.StatusForbidden)
+
+var (
+	tenantColumn     string
+	tenantColumnLock sync.RWMutex
+)
+
+// EnableTenant 开启多租户隔离 column 为租户列名 例如 "tenant_id"
+// 开启后包含该列的模型 Util[T] 的查询 更新 删除都会自动添加租户条件 创建时自动写入租户
+// 上下文中没有租户信息时操作返回 ErrTenantRequired 跨租户操作需使用 WithoutTenant
+func EnableTenant(column string) {
+	tenantColumnLock.Lock()
+	defer tenantColumnLock.Unlock()
+	tenantColumn = column
+}
+
+// getTenantColumn 获取租户列名 未开启时为空
+func getTenantColumn() string {
+	tenantColumnLock.RLock()
+	defer tenantColumnLock.RUnlock()
+	return tenantColumn
+}
+
+type (
+	tenantContextKey     struct{}
+	skipTenantContextKey struct{}
+)
+
+// WithTenant 将租户保存到上下文 一般在 BaseCtxFunc 中根据登录信息设置
+func WithTenant(ctx context.Context, tenantID interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext 获取上下文中的租户
+func TenantFromContext(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tenantID := ctx.Value(tenantContextKey{})
+	return tenantID, tenantID != nil
+}
+
+// WithoutTenant 返回跳过租户隔离的上下文 仅用于跨租户的管理操作
+func WithoutTenant(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, skipTenantContextKey{}, true)
+}
+
+// isSkipTenant 上下文是否跳过租户隔离
+func isSkipTenant(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipTenantContextKey{}).(bool)
+	return skip
+}
+
+// tenantField 获取模型的租户字段 未开启租户隔离或模型没有租户列时返回nil
+func (u *Util[T]) tenantField() *schema.Field {
+	column := getTenantColumn()
+	if column == "" {
+		return nil
+	}
+	sch, err := u.schema()
+	if err != nil {
+		return nil
+	}
+	return sch.FieldsByDBName[column]
+}
+
+// tenantScope 添加租户条件 上下文缺少租户时返回 ErrTenantRequired
+func (u *Util[T]) tenantScope(db *gorm.DB) *gorm.DB {
+	field := u.tenantField()
+	if field == nil || isSkipTenant(u.ctx) {
+		return db
+	}
+	tenantID, ok := TenantFromContext(u.ctx)
+	if !ok {
+		_ = db.AddError(ErrTenantRequired)
+		return db
+	}
+	return db.Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+		Value:  tenantID,
+	})
+}
+
+// stampTenant 将上下文中的租户写入模型 防止创建或更新到其他租户 models 为 *T 或 []T
+func (u *Util[T]) stampTenant(models interface{}) error {
+	field := u.tenantField()
+	if field == nil || isSkipTenant(u.ctx) {
+		return nil
+	}
+	tenantID, ok := TenantFromContext(u.ctx)
+	if !ok {
+		return ErrTenantRequired
+	}
+	ctx := context.Background()
+	rv := reflect.Indirect(reflect.ValueOf(models))
+	if rv.Kind() != reflect.Slice {
+		return field.Set(ctx, rv, tenantID)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := field.Set(ctx, rv.Index(i), tenantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
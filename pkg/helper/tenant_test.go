@@ -0,0 +1,120 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// setupTenant 开启租户隔离 返回租户1的 Util
+func setupTenant(t *testing.T) (*gorm.DB, *Util[isolatedItem]) {
+	t.Helper()
+	db := seedIsolated(t)
+	EnableTenant("tenant_id")
+	t.Cleanup(func() { EnableTenant("") })
+	return db, NewUtil[isolatedItem](db).WithContext(WithTenant(context.Background(), uint(1)))
+}
+
+func TestTenantGetListOrWhere(t *testing.T) {
+	_, u := setupTenant(t)
+	list, err := u.GetList(orWhereRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOwned(t, list.Data)
+	if list.Total != 1 || len(list.Data) != 1 {
+		t.Fatalf("期望1条记录 实际 total=%d len=%d", list.Total, len(list.Data))
+	}
+}
+
+func TestTenantGetListAsOrWhere(t *testing.T) {
+	_, u := setupTenant(t)
+	list, err := GetListAs[isolatedItem, isolatedItem](u, orWhereRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOwned(t, list.Data)
+}
+
+func TestTenantGetOne(t *testing.T) {
+	_, u := setupTenant(t)
+	item := &isolatedItem{ID: 3}
+	err := u.GetOne(item)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("期望记录不存在 实际 %v %+v", err, item)
+	}
+	if _, err = u.GetById(3); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetById 期望记录不存在 实际 %v", err)
+	}
+}
+
+func TestTenantUpdate(t *testing.T) {
+	db, u := setupTenant(t)
+	if err := u.UpdateOne(&isolatedItem{ID: 3, Name: "hacked"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.UpdateOneColumn(&isolatedItem{ID: 3, Name: "hacked"}, "name"); err != nil {
+		t.Fatal(err)
+	}
+	var item isolatedItem
+	db.First(&item, 3)
+	if item.Name != "secret" || item.TenantID != 2 {
+		t.Fatalf("其他租户的数据被修改: %+v", item)
+	}
+}
+
+func TestTenantDelete(t *testing.T) {
+	db, u := setupTenant(t)
+	if err := u.DeleteOne(&isolatedItem{ID: 3}); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&isolatedItem{}).Where("id = ?", 3).Count(&count)
+	if count != 1 {
+		t.Fatal("其他租户的数据被删除")
+	}
+}
+
+func TestTenantCursorOrWhere(t *testing.T) {
+	_, u := setupTenant(t)
+	list, err := u.GetCursorList(orWhereRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOwned(t, list.Data)
+	if len(list.Data) != 1 {
+		t.Fatalf("期望1条记录 实际%d条", len(list.Data))
+	}
+}
+
+func TestTenantAggregateOrWhere(t *testing.T) {
+	_, u := setupTenant(t)
+	result, err := u.Aggregate(orWhereRequest(), AggregateRequest{}, AggregateWhitelist{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Rows[0].Values["count"]; got != 1 {
+		t.Fatalf("期望统计1条 实际%v", got)
+	}
+}
+
+func TestTenantFindInBatchesOrWhere(t *testing.T) {
+	_, u := setupTenant(t)
+	err := u.FindInBatches(orWhereRequest(), 10, func(batch []isolatedItem, _ BatchProgress) error {
+		assertOwned(t, batch)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTenantRequired(t *testing.T) {
+	db, _ := setupTenant(t)
+	_, err := NewUtil[isolatedItem](db).GetList(NewPageReq())
+	if !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("期望 ErrTenantRequired 实际 %v", err)
+	}
+}
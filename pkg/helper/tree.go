@@ -0,0 +1,390 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrTreeCycle 移动节点时目标父节点是自身或子节点
+var ErrTreeCycle = NewErrorModel(ERROR, "不能移动到自身或子节点下", nil, http.StatusBadRequest)
+
+// TreeOptions 树形模型的列配置 ParentColumn 和 PathColumn 至少设置一个 默认使用 parent_id
+type TreeOptions struct {
+	ParentColumn string // 父节点列(邻接表) 根节点为0或NULL
+	PathColumn   string // 物化路径列 保存所有祖先的主键 例如 /1/3/ 根节点为 /
+}
+
+// TreeNode 树形结构的节点
+type TreeNode[T any] struct {
+	Data     T              `json:"data"`
+	Children []*TreeNode[T] `json:"children"`
+}
+
+// treeFields 树形模型的字段
+type treeFields struct {
+	primary *schema.Field
+	parent  *schema.Field
+	path    *schema.Field
+}
+
+// BuildTree 将平铺的列表组装为树 父节点不在列表中的节点作为根节点 节点顺序与列表一致
+//
+//	tree := helper.BuildTree(menus, func(m *Menu) uint { return m.ID }, func(m *Menu) uint { return m.ParentID })
+func BuildTree[T any, K comparable](list []T, id func(*T) K, parent func(*T) K) []*TreeNode[T] {
+	nodes := make([]*TreeNode[T], len(list))
+	index := make(map[K]*TreeNode[T], len(list))
+	for i := range list {
+		nodes[i] = &TreeNode[T]{Data: list[i], Children: make([]*TreeNode[T], 0)}
+		index[id(&list[i])] = nodes[i]
+	}
+	roots := make([]*TreeNode[T], 0)
+	for i := range list {
+		if p, ok := index[parent(&list[i])]; ok && p != nodes[i] {
+			p.Children = append(p.Children, nodes[i])
+		} else {
+			roots = append(roots, nodes[i])
+		}
+	}
+	return roots
+}
+
+// SetTree 设置树形模型的列 未设置时使用 parent_id 作为父节点列
+//
+//	dao.SetTree(helper.TreeOptions{ParentColumn: "parent_id", PathColumn: "path"})
+func (u *Util[T]) SetTree(options TreeOptions) {
+	u.tree = &options
+}
+
+// treeFields 获取树形模型的字段 列不存在时返回错误
+func (u *Util[T]) treeFields() (*treeFields, error) {
+	options := TreeOptions{ParentColumn: "parent_id"}
+	if u.tree != nil {
+		options = *u.tree
+	}
+	sch, err := u.schema()
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("模型%s没有主键", sch.Name)
+	}
+	fields := &treeFields{primary: sch.PrioritizedPrimaryField}
+	if options.ParentColumn != "" {
+		if fields.parent = sch.LookUpField(options.ParentColumn); fields.parent == nil {
+			return nil, fmt.Errorf("模型%s没有父节点列%s", sch.Name, options.ParentColumn)
+		}
+	}
+	if options.PathColumn != "" {
+		if fields.path = sch.LookUpField(options.PathColumn); fields.path == nil {
+			return nil, fmt.Errorf("模型%s没有路径列%s", sch.Name, options.PathColumn)
+		}
+	}
+	if fields.parent == nil && fields.path == nil {
+		return nil, errors.New("树形模型需要设置父节点列或路径列")
+	}
+	return fields, nil
+}
+
+// key 节点的主键 转为字符串便于比较
+func (f *treeFields) key(node any) string {
+	return treeKey(f.primary, node)
+}
+
+// parentKey 节点的父节点主键 根节点为空
+func (f *treeFields) parentKey(node any) string {
+	if f.parent != nil {
+		return treeKey(f.parent, node)
+	}
+	ids := pathIds(f.pathOf(node))
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[len(ids)-1]
+}
+
+// pathOf 节点的物化路径
+func (f *treeFields) pathOf(node any) string {
+	value, _ := f.path.ValueOf(context.Background(), reflect.Indirect(reflect.ValueOf(node)))
+	path := fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)))
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// childPrefix 子节点的物化路径
+func (f *treeFields) childPrefix(node any) string {
+	return f.pathOf(node) + treeKey(f.primary, node) + "/"
+}
+
+// treeKey 获取字段的值 零值及空指针返回空字符串
+func treeKey(field *schema.Field, node any) string {
+	value, zero := field.ValueOf(context.Background(), reflect.Indirect(reflect.ValueOf(node)))
+	if zero {
+		return ""
+	}
+	return fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)))
+}
+
+// pathIds 解析物化路径中的主键
+func pathIds(path string) []string {
+	ids := make([]string, 0)
+	for _, id := range strings.Split(path, "/") {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// isRootParent 父节点参数是否表示根节点
+func isRootParent(parentID interface{}) bool {
+	if parentID == nil {
+		return true
+	}
+	return reflect.ValueOf(parentID).IsZero()
+}
+
+// BuildTree 按模型的父节点列或路径列将列表组装为树
+func (u *Util[T]) BuildTree(list []T) ([]*TreeNode[T], error) {
+	fields, err := u.treeFields()
+	if err != nil {
+		return nil, err
+	}
+	return BuildTree(list,
+		func(node *T) string { return fields.key(node) },
+		func(node *T) string { return fields.parentKey(node) },
+	), nil
+}
+
+// GetDescendants 获取节点的所有子孙节点 不包含节点本身
+func (u *Util[T]) GetDescendants(id interface{}) ([]T, error) {
+	fields, err := u.treeFields()
+	if err != nil {
+		return nil, err
+	}
+	node, err := u.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+	order := clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: fields.primary.DBName}}
+	descendants := make([]T, 0)
+	if fields.path != nil {
+		err = u.reader().Model(u.Model).Where(clause.Like{
+			Column: clause.Column{Table: clause.CurrentTable, Name: fields.path.DBName},
+			Value:  fields.childPrefix(node) + "%",
+		}).Order(order).Find(&descendants).Error
+		return descendants, err
+	}
+	// 邻接表逐层查询 已访问的节点不再查询 防止数据成环
+	visited := map[string]bool{fields.key(node): true}
+	level := []interface{}{id}
+	for len(level) > 0 {
+		children := make([]T, 0)
+		err = u.reader().Model(u.Model).Where(clause.IN{
+			Column: clause.Column{Table: clause.CurrentTable, Name: fields.parent.DBName},
+			Values: level,
+		}).Order(order).Find(&children).Error
+		if err != nil {
+			return nil, err
+		}
+		level = level[:0]
+		for i := range children {
+			key := fields.key(&children[i])
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			value, _ := fields.primary.ValueOf(context.Background(), reflect.ValueOf(&children[i]).Elem())
+			level = append(level, value)
+			descendants = append(descendants, children[i])
+		}
+	}
+	return descendants, nil
+}
+
+// GetSubtree 获取以节点为根的子树
+func (u *Util[T]) GetSubtree(id interface{}) (*TreeNode[T], error) {
+	node, err := u.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+	descendants, err := u.GetDescendants(id)
+	if err != nil {
+		return nil, err
+	}
+	// 只组装子孙节点 父节点不在子孙中的作为节点的子节点 数据成环时节点仍为根
+	children, err := u.BuildTree(descendants)
+	if err != nil {
+		return nil, err
+	}
+	return &TreeNode[T]{Data: *node, Children: children}, nil
+}
+
+// GetAncestors 获取节点的所有祖先节点 按从根节点到父节点的顺序
+func (u *Util[T]) GetAncestors(id interface{}) ([]T, error) {
+	fields, err := u.treeFields()
+	if err != nil {
+		return nil, err
+	}
+	node, err := u.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+	if fields.path != nil {
+		ids := pathIds(fields.pathOf(node))
+		found := make([]T, 0, len(ids))
+		if len(ids) > 0 {
+			values := make([]interface{}, 0, len(ids))
+			for _, id := range ids {
+				values = append(values, id)
+			}
+			err = u.reader().Model(u.Model).Where(clause.IN{
+				Column: clause.Column{Table: clause.CurrentTable, Name: fields.primary.DBName},
+				Values: values,
+			}).Find(&found).Error
+			if err != nil {
+				return nil, err
+			}
+		}
+		// 按路径中的顺序排列 不存在或不可见的祖先跳过
+		byKey := make(map[string]T, len(found))
+		for i := range found {
+			byKey[fields.key(&found[i])] = found[i]
+		}
+		ancestors := make([]T, 0, len(found))
+		for _, id := range ids {
+			if ancestor, ok := byKey[id]; ok {
+				ancestors = append(ancestors, ancestor)
+			}
+		}
+		return ancestors, nil
+	}
+	// 邻接表逐层向上查询 已访问的节点不再查询 防止数据成环
+	ancestors := make([]T, 0)
+	visited := map[string]bool{fields.key(node): true}
+	for {
+		value, zero := fields.parent.ValueOf(context.Background(), reflect.ValueOf(node).Elem())
+		parentKey := fields.parentKey(node)
+		if zero || visited[parentKey] {
+			break
+		}
+		visited[parentKey] = true
+		if node, err = u.GetById(reflect.Indirect(reflect.ValueOf(value)).Interface()); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return nil, err
+		}
+		ancestors = append([]T{*node}, ancestors...)
+	}
+	return ancestors, nil
+}
+
+// CreateNode 在父节点下创建节点 parentID 为nil或零值时创建根节点
+// 设置了路径列时根据父节点生成路径
+func (u *Util[T]) CreateNode(model *T, parentID interface{}) error {
+	fields, err := u.treeFields()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(model).Elem()
+	ctx := context.Background()
+	if fields.parent != nil && !isRootParent(parentID) {
+		if err = fields.parent.Set(ctx, rv, parentID); err != nil {
+			return err
+		}
+	}
+	if fields.path != nil {
+		path := "/"
+		if !isRootParent(parentID) {
+			parent, err := u.GetById(parentID)
+			if err != nil {
+				return err
+			}
+			path = fields.childPrefix(parent)
+		}
+		if err = fields.path.Set(ctx, rv, path); err != nil {
+			return err
+		}
+	}
+	return u.CreateOne(model)
+}
+
+// MoveNode 将节点移动到新的父节点下 parentID 为nil或零值时移动为根节点
+// 设置了路径列时同时更新所有子孙节点的路径 在事务中执行
+func (u *Util[T]) MoveNode(id interface{}, parentID interface{}) error {
+	fields, err := u.treeFields()
+	if err != nil {
+		return err
+	}
+	return WithTx(u.ctx, u.DB, func(ctx context.Context) error {
+		tu := u.WithContext(ctx)
+		node, err := tu.GetById(id)
+		if err != nil {
+			return err
+		}
+		prefix := "/"
+		if !isRootParent(parentID) {
+			parent, err := tu.GetById(parentID)
+			if err != nil {
+				return err
+			}
+			// 目标父节点不能是自身或子孙节点
+			ancestors, err := tu.GetAncestors(parentID)
+			if err != nil {
+				return err
+			}
+			for _, item := range append(ancestors, *parent) {
+				if fields.key(&item) == fields.key(node) {
+					return ErrTreeCycle
+				}
+			}
+			if fields.path != nil {
+				prefix = fields.childPrefix(parent)
+			}
+		}
+
+		columns := make(map[string]interface{})
+		if fields.parent != nil {
+			columns[fields.parent.DBName] = parentID
+		}
+		evict := []interface{}{id}
+		if fields.path != nil {
+			descendants, err := tu.GetDescendants(id)
+			if err != nil {
+				return err
+			}
+			for i := range descendants {
+				value, _ := fields.primary.ValueOf(context.Background(), reflect.ValueOf(&descendants[i]).Elem())
+				evict = append(evict, value)
+			}
+			columns[fields.path.DBName] = prefix
+			oldPrefix := fields.childPrefix(node)
+			newPrefix := prefix + fields.key(node) + "/"
+			pathColumn := clause.Column{Table: clause.CurrentTable, Name: fields.path.DBName}
+			err = tu.conn().Model(new(T)).Where(clause.Like{Column: pathColumn, Value: oldPrefix + "%"}).
+				Update(fields.path.DBName, gorm.Expr("REPLACE(?, ?, ?)", pathColumn, oldPrefix, newPrefix)).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = tu.conn().Model(new(T)).Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: fields.primary.DBName},
+			Value:  id,
+		}).Updates(columns).Error
+		if err != nil {
+			return err
+		}
+		tu.evictCacheIds(evict...)
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package helper
+
+import "testing"
+
+type treeNode struct {
+	ID       uint
+	ParentID uint
+	Path     string
+	Name     string
+}
+
+func TestGetSubtreeCycle(t *testing.T) {
+	db := openTestDB(t, &treeNode{})
+	// B 和 C 互为父节点
+	nodes := []treeNode{{ID: 1, ParentID: 2, Name: "B"}, {ID: 2, ParentID: 1, Name: "C"}}
+	if err := db.Create(&nodes).Error; err != nil {
+		t.Fatal(err)
+	}
+	tree, err := NewUtil[treeNode](db).GetSubtree(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Data.Name != "B" || len(tree.Children) != 1 || tree.Children[0].Data.Name != "C" {
+		t.Fatalf("子树不正确: %+v", tree)
+	}
+}
+
+func TestGetAncestorsMissing(t *testing.T) {
+	db := openTestDB(t, &treeNode{})
+	// 祖先2不存在
+	nodes := []treeNode{{ID: 1, Path: "/", Name: "root"}, {ID: 3, Path: "/1/2/", Name: "leaf"}}
+	if err := db.Create(&nodes).Error; err != nil {
+		t.Fatal(err)
+	}
+	u := NewUtil[treeNode](db)
+	u.SetTree(TreeOptions{PathColumn: "path"})
+	ancestors, err := u.GetAncestors(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ancestors) != 1 || ancestors[0].Name != "root" {
+		t.Fatalf("期望只返回存在的祖先 实际 %+v", ancestors)
+	}
+}
@@ -0,0 +1,103 @@
+package helper
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// txContextKey 事务在上下文中的key 按事务所属的数据库区分
+type txContextKey struct {
+	db *sql.DB
+}
+
+// txKey 获取db所属数据库的key 同一数据库的连接及其事务得到相同的key
+func txKey(db *gorm.DB) txContextKey {
+	sqlDB, _ := db.DB()
+	return txContextKey{db: sqlDB}
+}
+
+// WithTx 在事务中执行fn 事务保存在传给fn的上下文中
+// 使用该上下文的 Util[T].WithContext 会自动加入同一数据库的事务 其他数据库的 Util 不受影响
+// fn 返回错误或发生panic时回滚 上下文中已有同一数据库的事务时使用保存点实现嵌套事务
+//
+//	err := helper.WithTx(req.Ctx, db, func(ctx context.Context) error {
+//		if err := orderDao.WithContext(ctx).CreateOne(order); err != nil {
+//			return err
+//		}
+//		return stockDao.WithContext(ctx).UpdateOne(stock)
+//	})
+func WithTx(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	key := txKey(db)
+	if tx, ok := ctx.Value(key).(*gorm.DB); ok {
+		db = tx
+	}
+	parent, _ := ctx.Value(afterCommitContextKey(key)).(*afterCommitHooks)
+	hooks := &afterCommitHooks{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, key, tx)
+		return fn(context.WithValue(txCtx, afterCommitContextKey(key), hooks))
+	})
+	if err != nil {
+		return err
+	}
+	// 嵌套事务提交后交给外层事务 最外层事务提交后执行
+	if parent != nil {
+		parent.add(hooks.take()...)
+		return nil
+	}
+	for _, hook := range hooks.take() {
+		hook()
+	}
+	return nil
+}
+
+// afterCommitContextKey 事务提交后执行的函数在上下文中的key 按事务所属的数据库区分
+type afterCommitContextKey struct {
+	db *sql.DB
+}
+
+// afterCommitHooks 事务提交后执行的函数 事务回滚时丢弃
+type afterCommitHooks struct {
+	lock  sync.Mutex
+	hooks []func()
+}
+
+func (h *afterCommitHooks) add(hooks ...func()) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.hooks = append(h.hooks, hooks...)
+}
+
+func (h *afterCommitHooks) take() []func() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	hooks := h.hooks
+	h.hooks = nil
+	return hooks
+}
+
+// AfterCommit 在上下文中db所属数据库的事务提交后执行fn 事务回滚时不执行 上下文中没有该事务时立即执行
+func AfterCommit(ctx context.Context, db *gorm.DB, fn func()) {
+	if ctx != nil && db != nil {
+		if hooks, ok := ctx.Value(afterCommitContextKey(txKey(db))).(*afterCommitHooks); ok {
+			hooks.add(fn)
+			return
+		}
+	}
+	fn()
+}
+
+// TxFromContext 获取上下文中db所属数据库的事务
+func TxFromContext(ctx context.Context, db *gorm.DB) (*gorm.DB, bool) {
+	if ctx == nil || db == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txKey(db)).(*gorm.DB)
+	return tx, ok
+}
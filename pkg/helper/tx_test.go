@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type txOrder struct {
+	ID   uint
+	Name string
+}
+
+type logRow struct {
+	ID      uint
+	Message string
+}
+
+func TestWithTxOtherDatabase(t *testing.T) {
+	mainDB := openTestDB(t, &txOrder{})
+	logDB := openNamedTestDB(t, "_log", &logRow{})
+	RegisterDB("tx_test_log", logDB)
+	t.Cleanup(func() {
+		dbRegistryLock.Lock()
+		defer dbRegistryLock.Unlock()
+		delete(dbRegistry, "tx_test_log")
+	})
+	orders := NewUtil[txOrder](mainDB)
+	logs := NewUtilByName[logRow]("tx_test_log")
+
+	rollback := errors.New("rollback")
+	err := WithTx(context.Background(), mainDB, func(ctx context.Context) error {
+		if err := orders.WithContext(ctx).CreateOne(&txOrder{Name: "a"}); err != nil {
+			return err
+		}
+		// 其他数据库的 Util 不使用主库的事务
+		if err := logs.WithContext(ctx).CreateOne(&logRow{Message: "created"}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("got %v, want rollback", err)
+	}
+	var count int64
+	mainDB.Model(&txOrder{}).Count(&count)
+	if count != 0 {
+		t.Fatal("主库事务回滚后不应有数据")
+	}
+	logDB.Model(&logRow{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("日志库的写入不属于主库事务 期望1条 实际%d条", count)
+	}
+}
+
+func TestWithTxEachDatabase(t *testing.T) {
+	mainDB := openTestDB(t, &txOrder{})
+	logDB := openNamedTestDB(t, "_log", &logRow{})
+	orders := NewUtil[txOrder](mainDB)
+	logs := NewUtil[logRow](logDB)
+
+	err := WithTx(context.Background(), mainDB, func(ctx context.Context) error {
+		if err := orders.WithContext(ctx).CreateOne(&txOrder{Name: "a"}); err != nil {
+			return err
+		}
+		// 日志库的事务嵌套在主库事务中 两者互不影响
+		err := WithTx(ctx, logDB, func(ctx context.Context) error {
+			if _, ok := TxFromContext(ctx, mainDB); !ok {
+				t.Error("应保留主库的事务")
+			}
+			return logs.WithContext(ctx).CreateOne(&logRow{Message: "created"})
+		})
+		if err != nil {
+			return err
+		}
+		return orders.WithContext(ctx).CreateOne(&txOrder{Name: "b"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	mainDB.Model(&txOrder{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("期望主库2条 实际%d条", count)
+	}
+}
@@ -0,0 +1,170 @@
+package helper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// 密码强度规则 password 的要求 在启动时设置
+var (
+	PasswordMinLength  = 8 // 最小长度
+	PasswordMinClasses = 3 // 大写字母 小写字母 数字 特殊字符中至少包含的种类
+)
+
+var mobileRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// RegisterRule 注册自定义校验规则及各语言的错误信息 messages 的key为语言 {0}为字段名 {1}为规则参数
+// 需要在启动时调用
+//
+//	_ = helper.NewValidate().RegisterRule("username", func(fl validator.FieldLevel) bool {
+//		return usernameRegexp.MatchString(fl.Field().String())
+//	}, map[string]string{
+//		helper.LocaleZh: "{0}只能包含字母和数字",
+//		helper.LocaleEn: "{0} must be alphanumeric",
+//	})
+func (v *Validate) RegisterRule(tag string, fn validator.Func, messages map[string]string) error {
+	if err := v.validate.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+	return v.RegisterTranslation(tag, messages)
+}
+
+// registerBuiltinRules 注册内置的校验规则
+// mobile 手机号 idcard 身份证号 uscc 统一社会信用代码 password 密码强度
+func (v *Validate) registerBuiltinRules() error {
+	rules := []struct {
+		tag      string
+		fn       validator.Func
+		messages map[string]string
+		params   func(fe validator.FieldError) []string // 翻译参数 为空时使用规则参数
+	}{
+		{"mobile", isMobile, map[string]string{
+			LocaleZh: "{0}必须是有效的手机号",
+			LocaleEn: "{0} must be a valid mobile number",
+		}, nil},
+		{"idcard", isIDCard, map[string]string{
+			LocaleZh: "{0}必须是有效的身份证号",
+			LocaleEn: "{0} must be a valid ID card number",
+		}, nil},
+		{"uscc", isUSCC, map[string]string{
+			LocaleZh: "{0}必须是有效的统一社会信用代码",
+			LocaleEn: "{0} must be a valid unified social credit code",
+		}, nil},
+		{"password", isStrongPassword, map[string]string{
+			LocaleZh: "{0}至少{1}位 且需包含大写字母、小写字母、数字、特殊字符中的至少{2}种",
+			LocaleEn: "{0} must be at least {1} characters and contain at least {2} of uppercase letters, lowercase letters, digits and symbols",
+		}, func(validator.FieldError) []string {
+			// 在翻译时读取 启动时修改的要求也能反映在错误信息中
+			return []string{strconv.Itoa(PasswordMinLength), strconv.Itoa(PasswordMinClasses)}
+		}},
+	}
+	for _, rule := range rules {
+		if rule.params == nil {
+			if err := v.RegisterRule(rule.tag, rule.fn, rule.messages); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := v.validate.RegisterValidation(rule.tag, rule.fn); err != nil {
+			return err
+		}
+		if err := v.registerTranslation(rule.tag, rule.messages, rule.params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isMobile 中国大陆手机号
+func isMobile(fl validator.FieldLevel) bool {
+	return mobileRegexp.MatchString(fl.Field().String())
+}
+
+// isIDCard 中国大陆身份证号 支持18位(校验出生日期和校验码)及15位
+func isIDCard(fl validator.FieldLevel) bool {
+	id := strings.ToUpper(fl.Field().String())
+	switch len(id) {
+	case 15:
+		return isDigits(id) && isBirthday("19"+id[6:12])
+	case 18:
+		if !isDigits(id[:17]) || !isBirthday(id[6:14]) {
+			return false
+		}
+		weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+		sum := 0
+		for i, w := range weights {
+			sum += int(id[i]-'0') * w
+		}
+		return "10X98765432"[sum%11] == id[17]
+	}
+	return false
+}
+
+// isUSCC 统一社会信用代码 GB 32100-2015
+func isUSCC(fl validator.FieldLevel) bool {
+	const charset = "0123456789ABCDEFGHJKLMNPQRTUWXY"
+	code := strings.ToUpper(fl.Field().String())
+	if len(code) != 18 {
+		return false
+	}
+	weights := []int{1, 3, 9, 27, 19, 26, 16, 17, 20, 29, 25, 13, 8, 24, 10, 30, 28}
+	sum := 0
+	for i, w := range weights {
+		index := strings.IndexByte(charset, code[i])
+		if index < 0 {
+			return false
+		}
+		sum += index * w
+	}
+	check := (31 - sum%31) % 31
+	return charset[check] == code[17]
+}
+
+// isStrongPassword 密码强度 长度及包含的字符种类满足 PasswordMinLength PasswordMinClasses
+func isStrongPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len([]rune(password)) < PasswordMinLength {
+		return false
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{upper, lower, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	return classes >= PasswordMinClasses
+}
+
+// isDigits 是否全部为数字
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isBirthday 是否为有效的出生日期 格式为 20060102
+func isBirthday(s string) bool {
+	birthday, err := time.Parse("20060102", s)
+	return err == nil && !birthday.After(time.Now())
+}
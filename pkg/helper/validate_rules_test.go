@@ -0,0 +1,41 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type ruleForm struct {
+	Mobile   string `json:"mobile" binding:"mobile"`
+	Password string `json:"password" binding:"password"`
+}
+
+// 内置规则在包初始化时注册 未调用 NewValidate 也可以使用
+func TestBuiltinRulesRegisteredAtInit(t *testing.T) {
+	err := binding.Validator.ValidateStruct(&ruleForm{Mobile: "13800138000", Password: "Abc12345"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = binding.Validator.ValidateStruct(&ruleForm{Mobile: "123", Password: "Abc12345"}); err == nil {
+		t.Fatal("期望手机号校验失败")
+	}
+}
+
+func TestPasswordMessageFollowsSettings(t *testing.T) {
+	length := PasswordMinLength
+	PasswordMinLength = 10
+	t.Cleanup(func() { PasswordMinLength = length })
+
+	obj := &ruleForm{Mobile: "13800138000", Password: "Abc12345"}
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		t.Fatal("期望密码校验失败")
+	}
+	r := &Request{}
+	message := r.GetValidateErr(err, obj).Message
+	if !strings.Contains(message, "10") {
+		t.Fatalf("错误信息应包含修改后的长度: %s", message)
+	}
+}
@@ -8,6 +8,8 @@ type ErrorModel struct {
 	Message    string      `json:"message" `
 	Result     interface{} `json:"result"`
 	HttpStatus int         `json:"httpStatus" swaggerignore:"true"`
+
+	cause error // 原始错误
 }
 
 func NewErrorModel(code int, message string, result interface{}, httpStatus int) *ErrorModel {
@@ -17,3 +19,15 @@ func NewErrorModel(code int, message string, result interface{}, httpStatus int)
 func (e *ErrorModel) Error() string {
 	return e.Message
 }
+
+// Wrap 返回携带原始错误的副本 可通过 errors.Is/errors.As 判断原始错误
+func (e *ErrorModel) Wrap(err error) *ErrorModel {
+	clone := *e
+	clone.cause = err
+	return &clone
+}
+
+// Unwrap 获取原始错误
+func (e *ErrorModel) Unwrap() error {
+	return e.cause
+}
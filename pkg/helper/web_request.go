@@ -2,15 +2,18 @@ package helper
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
 	"sync"
 
 	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
 	"github.com/go-playground/locales/zh"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
 	zh_translations "github.com/go-playground/validator/v10/translations/zh"
 )
 
@@ -32,68 +35,159 @@ var (
 	validateOnce sync.Once
 )
 
+// 包初始化时注册中英文翻译及内置规则 保证首次绑定请求前内置规则已可用
+func init() {
+	NewValidate()
+}
+
+// NewValidate 获取校验器 中英文翻译及内置规则(mobile idcard uscc password)在包初始化时注册
 func NewValidate() *Validate {
 	validateOnce.Do(func() {
 		validate = &Validate{}
-		//注册翻译器
+		//注册翻译器 默认使用中文
 		zh_ := zh.New()
-		uni := ut.New(zh_, zh_)
-		trans, _ := uni.GetTranslator("zh")
+		uni := ut.New(zh_, zh_, en.New())
+		zhTrans, _ := uni.GetTranslator(LocaleZh)
+		enTrans, _ := uni.GetTranslator(LocaleEn)
 		//获取gin的校验器
 		val := binding.Validator.Engine().(*validator.Validate)
 		//注册翻译器
-		_ = zh_translations.RegisterDefaultTranslations(val, trans)
+		_ = zh_translations.RegisterDefaultTranslations(val, zhTrans)
+		_ = en_translations.RegisterDefaultTranslations(val, enTrans)
 		validate.validate = val
 		validate.uni = uni
-		validate.trans = trans
+		validate.trans = zhTrans
+		//注册内置的校验规则
+		_ = validate.registerBuiltinRules()
 	})
 	return validate
 }
 
+// Translator 获取语言对应的翻译器 不支持的语言使用 DefaultLocale
+func (v *Validate) Translator(locale string) ut.Translator {
+	if trans, found := v.uni.GetTranslator(locale); found {
+		return trans
+	}
+	if trans, found := v.uni.GetTranslator(DefaultLocale); found {
+		return trans
+	}
+	return v.trans
+}
+
+// RegisterTranslation 注册校验规则的翻译 messages 的key为语言 {0}为字段名 {1}为规则参数
+// 需要在启动时调用 可覆盖内置规则的翻译
+//
+//	_ = helper.NewValidate().RegisterTranslation("required", map[string]string{
+//		helper.LocaleZh: "{0}不能为空",
+//		helper.LocaleEn: "{0} is required",
+//	})
+func (v *Validate) RegisterTranslation(tag string, messages map[string]string) error {
+	return v.registerTranslation(tag, messages, func(fe validator.FieldError) []string {
+		return []string{fe.Param()}
+	})
+}
+
+// registerTranslation 注册校验规则的翻译 params 返回{1}开始的参数 在翻译时获取
+func (v *Validate) registerTranslation(tag string, messages map[string]string, params func(fe validator.FieldError) []string) error {
+	for locale, message := range messages {
+		trans, found := v.uni.GetTranslator(locale)
+		if !found {
+			return fmt.Errorf("不支持的语言: %s", locale)
+		}
+		err := v.validate.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+			return ut.Add(tag, message, true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			text, err := ut.T(fe.Tag(), append([]string{fe.Field()}, params(fe)...)...)
+			if err != nil {
+				return fe.Error()
+			}
+			return text
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Request struct {
 	validateTags []string
+	locale       string // 校验错误信息的语言 为空时使用 DefaultLocale
+}
+
+// SetLocale 设置校验错误信息的语言
+func (r *Request) SetLocale(locale string) {
+	r.locale = locale
+}
+
+// ValidateAllErrors 为true时 GetValidateErr 将所有字段的错误放入 ErrorModel.Result 默认false 只返回第一个错误
+var ValidateAllErrors = false
+
+// ValidateError 字段的校验错误
+type ValidateError struct {
+	Field   string `json:"field"`   // 字段名 取 json form uri query header 标签
+	Tag     string `json:"tag"`     // 校验规则 例如 required
+	Message string `json:"message"` // 翻译后的错误信息
+	Param   string `json:"param"`   // 校验规则的参数 例如 max=10 中的10
 }
 
 // GetValidateErr 获取校验错误信息 传入错误对象和对象 对象tag为json form uri query header
+// Message 为第一个错误 ValidateAllErrors 为true时 Result 为所有错误的 []ValidateError
 func (r *Request) GetValidateErr(err error, obj interface{}) *ErrorModel {
-	v := NewValidate()
-	getObj := reflect.TypeOf(obj)
-	var result *ErrorModel
 	var errs validator.ValidationErrors
 	// 判断err 是否是 validator.ValidationErrors 类型
-	if !errors.As(err, &errs) {
-		result = NewErrorModel(ERROR, err.Error(), nil, http.StatusPreconditionFailed)
-	} else {
-
-		for _, err := range errs {
-			if f, exist := getObj.Elem().FieldByName(err.Field()); exist {
-				var tag string
-				for _, tagValueStr := range r.validateTags {
-					tagStr, ok := f.Tag.Lookup(tagValueStr)
-					if ok {
-						tag = tagStr
-						break
-					}
-				}
-				result = NewErrorModel(
-					ERROR,
-					tag+strings.Replace(err.Translate(v.trans), err.Field(), "", -1),
-					nil,
-					http.StatusPreconditionFailed,
-				)
-			} else {
-				result = NewErrorModel(
-					ERROR,
-					err.Translate(v.trans),
-					nil,
-					http.StatusPreconditionFailed,
-				)
-			}
-			return result
+	if !errors.As(err, &errs) || len(errs) == 0 {
+		return NewErrorModel(ERROR, err.Error(), nil, http.StatusPreconditionFailed)
+	}
+	trans := NewValidate().Translator(r.locale)
+	details := make([]ValidateError, 0, len(errs))
+	for _, fieldErr := range errs {
+		details = append(details, r.validateError(fieldErr, obj, trans))
+		if !ValidateAllErrors {
+			break
 		}
+	}
+	var result interface{}
+	if ValidateAllErrors {
+		result = details
+	}
+	return NewErrorModel(ERROR, details[0].Message, result, http.StatusPreconditionFailed)
+}
 
+// validateError 转换单个字段的校验错误 字段名取第一个存在的标签
+func (r *Request) validateError(err validator.FieldError, obj interface{}, trans ut.Translator) ValidateError {
+	detail := ValidateError{Field: err.Field(), Tag: err.Tag(), Param: err.Param()}
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		detail.Message = err.Translate(trans)
+		return detail
+	}
+	f, exist := t.FieldByName(err.StructField())
+	if !exist {
+		detail.Message = err.Translate(trans)
+		return detail
+	}
+	var label string
+	for _, tagValueStr := range r.validateTags {
+		tagStr, ok := f.Tag.Lookup(tagValueStr)
+		if name := strings.Split(tagStr, ",")[0]; ok && name != "" && name != "-" {
+			label = name
+			break
+		}
+	}
+	if label != "" {
+		detail.Field = label
 	}
-	return result
+	detail.Message = validateMessage(err, label, trans)
+	return detail
+}
+
+// validateMessage 翻译校验错误 将消息中的字段名替换为label
+func validateMessage(err validator.FieldError, label string, trans ut.Translator) string {
+	return label + strings.Replace(err.Translate(trans), err.Field(), "", -1)
 }
 
 func NewRequest() *Request {
@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type allErrorsForm struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+}
+
+func TestGetValidateErrFirstByDefault(t *testing.T) {
+	obj := &allErrorsForm{}
+	e := (&Request{}).GetValidateErr(binding.Validator.ValidateStruct(obj), obj)
+	if e.Result != nil {
+		t.Fatalf("默认不应返回所有错误: %+v", e.Result)
+	}
+}
+
+func TestGetValidateErrAll(t *testing.T) {
+	ValidateAllErrors = true
+	t.Cleanup(func() { ValidateAllErrors = false })
+
+	obj := &allErrorsForm{}
+	e := (&Request{}).GetValidateErr(binding.Validator.ValidateStruct(obj), obj)
+	details, ok := e.Result.([]ValidateError)
+	if !ok || len(details) != 2 {
+		t.Fatalf("期望返回2个错误 实际 %+v", e.Result)
+	}
+	if e.Message != details[0].Message {
+		t.Fatalf("Message 应为第一个错误: %s", e.Message)
+	}
+}
@@ -19,16 +19,31 @@ func NewResponse(code int, message string, result any) *Response {
 
 // PageList  分页数据
 type PageList[T interface{}] struct {
-	Total    int64 `json:"total" `
-	Data     []T   `json:"data" `
-	Page     int   `json:"page" `
-	PageSize int   `json:"page_size" `
+	Total      int64 `json:"total" `
+	Data       []T   `json:"data" `
+	Page       int   `json:"page" `
+	PageSize   int   `json:"page_size" `
+	TotalExact bool  `json:"total_exact" ` // 总数是否为精确值
+	HasMore    bool  `json:"has_more" `    // 是否还有下一页
 }
 
 func NewPageList[T interface{}]() *PageList[T] {
 	return &PageList[T]{}
 }
 
+// CursorPageList 游标分页数据 不返回总数
+type CursorPageList[T interface{}] struct {
+	Data       []T    `json:"data" `
+	PageSize   int    `json:"page_size" `
+	NextCursor string `json:"next_cursor" `
+	PrevCursor string `json:"prev_cursor" `
+	HasMore    bool   `json:"has_more" `
+}
+
+func NewCursorPageList[T interface{}]() *CursorPageList[T] {
+	return &CursorPageList[T]{}
+}
+
 // DefaultResult 默认的返回数据结构,用于services处理完业务逻辑后返回给controller的数据结构
 type DefaultResult struct {
 	Err  *ErrorModel `json:"err"`
@@ -63,6 +78,11 @@ func (r *DefaultResult) SetError(err error) {
 		r.Err = errModel
 		return
 	}
+	// 数据库错误翻译为对应的http状态码
+	if errModel = TranslateDBError(err); errModel != nil {
+		r.Err = errModel
+		return
+	}
 	r.Err = NewErrorModel(
 		-1,
 		err.Error(),